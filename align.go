@@ -59,6 +59,21 @@ func (a *Align) SetFocused(b bool) {
 	}
 }
 
+func (a *Align) FocusRect(rect Rect) (Rect, bool) {
+	if a.Child == nil {
+		return Rect{}, false
+	}
+	return focusRectOf(a.Child, a.GetChildRect(rect))
+}
+
+// TakeDamage reports the damage of the Child.
+func (a *Align) TakeDamage() ([]Rect, bool) {
+	if a.Child == nil {
+		return nil, false
+	}
+	return damageOf(a.Child)
+}
+
 func (a *Align) DisplaySize(boundsW, boundsH int) (w, h int) {
 	if a.Child != nil {
 		switch a.Positioning {
@@ -1,6 +1,9 @@
 package dos
 
 import (
+	"sync"
+	"time"
+
 	"github.com/gdamore/tcell/v2"
 )
 
@@ -17,6 +20,178 @@ type App struct {
 	// OnMouseEvent is called before the MainWidget's handler, and if this function
 	// returns true, then the event is never passed onto the main widget.
 	OnMouseEvent func(ev *tcell.EventMouse) bool
+
+	mu         sync.Mutex
+	screen     tcell.Screen // Set while running
+	pending    []func()     // Callbacks posted before running
+	redrawing  bool         // A redraw event was posted and not handled yet
+	updating   bool         // RequestUpdate was called since the last redraw event
+	damaged    []Rect       // Parts of the screen to redraw
+	damagedAll bool         // The whole screen must be redrawn
+}
+
+// An eventFunc is posted to the tcell.Screen to run a callback on the UI
+// goroutine. A nil fn only causes a redraw.
+type eventFunc struct {
+	when time.Time
+	fn   func()
+}
+
+func (ev *eventFunc) When() time.Time {
+	return ev.when
+}
+
+// Post calls the function on the goroutine running the App's event loop, then
+// redraws. It is safe to call from any goroutine, and before the App runs, in
+// which case the function is called once it does. Returns an error if the
+// event queue of the tcell.Screen is full.
+func (app *App) Post(fn func()) error {
+	app.mu.Lock()
+	s := app.screen
+	if s == nil {
+		app.pending = append(app.pending, fn)
+		app.mu.Unlock()
+		return nil
+	}
+	app.mu.Unlock()
+	return s.PostEvent(&eventFunc{time.Now(), fn})
+}
+
+// RequestRedraw has the App redraw the whole screen as soon as possible. It is
+// safe to call from any goroutine. Many requests before the redraw cause only
+// one redraw.
+func (app *App) RequestRedraw() {
+	app.Invalidate()
+}
+
+// Invalidate has the App redraw the rects of the screen as soon as possible, or
+// the whole screen if none are given. Only the cells inside the rects change,
+// so a widget that changed without handling an event, like one updated from
+// another goroutine, can pass its own rect to have only itself redrawn. It is
+// safe to call from any goroutine.
+func (app *App) Invalidate(rects ...Rect) {
+	app.mu.Lock()
+	if len(rects) == 0 {
+		app.damagedAll = true
+	} else {
+		app.addDamage(rects)
+	}
+	app.mu.Unlock()
+	app.wake()
+}
+
+// RequestUpdate has the App redraw the damage reported by the MainWidget as
+// soon as possible, see DamageReporter. It is for widgets that change without
+// handling an event and do not know the App, like a Terminal receiving output.
+// If the MainWidget is not a DamageReporter, the whole screen is redrawn. It is
+// safe to call from any goroutine.
+func (app *App) RequestUpdate() {
+	app.mu.Lock()
+	app.updating = true
+	app.mu.Unlock()
+	app.wake()
+}
+
+// wake posts an event to have the event loop redraw, unless one is posted
+// already.
+func (app *App) wake() {
+	app.mu.Lock()
+	s := app.screen
+	if s == nil || app.redrawing {
+		app.mu.Unlock()
+		return
+	}
+	app.redrawing = true
+	app.mu.Unlock()
+	if s.PostEvent(&eventFunc{when: time.Now()}) != nil {
+		app.mu.Lock()
+		app.redrawing = false // Queue is full, so a redraw is coming anyway
+		app.mu.Unlock()
+	}
+}
+
+// addDamage records rects of the screen to redraw. The mutex must be held.
+func (app *App) addDamage(rects []Rect) {
+	if app.damagedAll {
+		return
+	}
+	app.damaged = append(app.damaged, rects...)
+	if len(app.damaged) > maxDamagedRects {
+		app.damagedAll = true // Cheaper to redraw everything
+	}
+}
+
+// maxDamagedRects is the number of rects passed to Invalidate before the App
+// redraws the whole screen instead.
+const maxDamagedRects = 32
+
+// damageAll has the whole screen redrawn by the event loop, without posting an
+// event to wake it.
+func (app *App) damageAll() {
+	app.mu.Lock()
+	app.damagedAll = true
+	app.mu.Unlock()
+}
+
+// damageMainWidget has the damage reported by the MainWidget redrawn by the
+// event loop. Must be called on the App's goroutine.
+func (app *App) damageMainWidget() {
+	rects, all := damageOf(app.MainWidget)
+	app.mu.Lock()
+	if all {
+		app.damagedAll = true
+	} else {
+		app.addDamage(rects)
+	}
+	app.mu.Unlock()
+}
+
+// takeDamage returns the parts of the screen that must be redrawn, and forgets
+// them.
+func (app *App) takeDamage() (rects []Rect, all bool) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	rects, all = app.damaged, app.damagedAll
+	app.damaged, app.damagedAll = nil, false
+	return rects, all
+}
+
+// AfterFunc calls the function on the App's goroutine after the duration, then
+// redraws. Stopping the returned timer before it fires cancels the call.
+func (app *App) AfterFunc(d time.Duration, fn func()) *time.Timer {
+	return time.AfterFunc(d, func() { _ = app.Post(fn) })
+}
+
+// Every calls the function on the App's goroutine each time the duration
+// passes, then redraws, like for animation or a clock. Calling the returned
+// function stops it. Ticks are dropped if the App falls behind.
+func (app *App) Every(d time.Duration, fn func()) (stop func()) {
+	ticker := time.NewTicker(d)
+	done := make(chan struct{})
+	tick := func() {
+		select {
+		case <-done: // Stopped after the tick was posted
+		default:
+			fn()
+		}
+	}
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				_ = app.Post(tick)
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
 }
 
 func (app *App) Run(s tcell.Screen) {
@@ -24,24 +199,75 @@ func (app *App) Run(s tcell.Screen) {
 	s.EnablePaste()
 	app.MainWidget.SetFocused(true)
 	app.Running = true
+
+	app.mu.Lock()
+	app.screen = s
+	pending := app.pending
+	app.pending = nil
+	app.mu.Unlock()
+	for _, fn := range pending {
+		_ = s.PostEvent(&eventFunc{time.Now(), fn})
+	}
+
 	if app.CustomEventLoop != nil {
 		app.CustomEventLoop(app, s)
 	} else {
 		DefaultEventLoop(app, s)
 	}
+
+	app.mu.Lock()
+	app.screen = nil
+	app.mu.Unlock()
 	s.Fini()
 }
 
+// HandleEvent performs events posted with Post, RequestRedraw, RequestUpdate,
+// AfterFunc and Every. Returns false for any other event. A CustomEventLoop
+// should call this for each event it does not handle itself.
+func (app *App) HandleEvent(ev tcell.Event) bool {
+	e, ok := ev.(*eventFunc)
+	if !ok {
+		return false
+	}
+	if e.fn == nil {
+		app.mu.Lock()
+		app.redrawing = false
+		updating := app.updating
+		app.updating = false
+		app.mu.Unlock()
+		if updating {
+			app.damageMainWidget()
+		}
+	} else {
+		e.fn()
+		app.damageAll()
+	}
+	return true
+}
+
+// DefaultEventLoop draws the MainWidget and passes it events until the App is
+// no longer Running. The screen is only redrawn after an event that may have
+// changed it, and after calls to Invalidate, which may redraw only part of it.
+// After a key is handled by the MainWidget, only its damage is redrawn if it is
+// a DamageReporter; unhandled keys do not redraw. Moving the mouse without
+// pressing buttons only redraws if a handler returns true.
 func DefaultEventLoop(app *App, s tcell.Screen) {
 	w, h := s.Size()
+	var prevButtons tcell.ButtonMask
+	app.damageAll()
 	for app.Running {
-		s.Clear()
-		s.Fill(app.ClearRune, app.ClearStyle)
-
 		rect := Rect{0, 0, w, h}
-		app.MainWidget.Draw(rect, s)
-
-		s.Show() // Renders all changed cells
+		if damaged, all := app.takeDamage(); all {
+			s.Fill(app.ClearRune, app.ClearStyle)
+			app.MainWidget.Draw(rect, s)
+			s.Show() // Renders all changed cells
+		} else if len(damaged) > 0 {
+			for _, r := range damaged {
+				DrawRect(r, app.ClearRune, app.ClearStyle, s)
+			}
+			app.MainWidget.Draw(rect, &damageScreen{s, damaged})
+			s.Show()
+		}
 
 		switch ev := s.PollEvent().(type) {
 		case *tcell.EventResize:
@@ -50,20 +276,46 @@ func DefaultEventLoop(app *App, s tcell.Screen) {
 				app.OnResize(w, h)
 			}
 			s.Sync() // Redraw the entire screen
+			app.damageAll()
 		case *tcell.EventKey:
-			if app.OnKeyEvent != nil {
-				if app.OnKeyEvent(ev) {
-					break
-				}
+			if app.OnKeyEvent != nil && app.OnKeyEvent(ev) {
+				app.damageAll() // Could have changed anything
+			} else if app.MainWidget.HandleKey(ev) {
+				app.damageMainWidget()
 			}
-			_ = app.MainWidget.HandleKey(ev)
 		case *tcell.EventMouse:
-			if app.OnMouseEvent != nil {
-				if app.OnMouseEvent(ev) {
-					break
-				}
+			handled := app.OnMouseEvent != nil && app.OnMouseEvent(ev)
+			if !handled {
+				handled = app.MainWidget.HandleMouse(rect, ev)
 			}
-			_ = app.MainWidget.HandleMouse(rect, ev)
+			// Presses and releases may change focus even when unhandled
+			if handled || ev.Buttons() != prevButtons {
+				app.damageAll()
+			}
+			prevButtons = ev.Buttons()
+		default:
+			app.HandleEvent(ev)
+		}
+	}
+}
+
+// A damageScreen draws only the cells inside its rects.
+type damageScreen struct {
+	tcell.Screen
+	rects []Rect
+}
+
+func (s *damageScreen) SetContent(x, y int, mainc rune, combc []rune, style tcell.Style) {
+	for _, r := range s.rects {
+		if r.HasPoint(x, y) {
+			s.Screen.SetContent(x, y, mainc, combc, style)
+			return
 		}
 	}
 }
+
+func (s *damageScreen) Fill(r rune, style tcell.Style) {
+	for _, rect := range s.rects {
+		DrawRect(rect, r, style, s.Screen)
+	}
+}
@@ -0,0 +1,126 @@
+package dos
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// runTestApp runs the App on a new tcell.SimulationScreen in another
+// goroutine. The returned channel is closed when the App stops.
+func runTestApp(t *testing.T, app *App) (tcell.SimulationScreen, chan struct{}) {
+	t.Helper()
+	s := tcell.NewSimulationScreen("")
+	if err := s.Init(); err != nil { // Run calls Fini
+		t.Fatal(err)
+	}
+	s.SetSize(20, 3)
+	done := make(chan struct{})
+	go func() {
+		app.Run(s)
+		close(done)
+	}()
+	return s, done
+}
+
+// wait fails the test if the channel is not closed soon.
+func wait(t *testing.T, ch chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+func TestAppPost(t *testing.T) {
+	label := &Label{Text: "before"}
+	app := &App{MainWidget: label}
+	posted := make(chan struct{})
+	_ = app.Post(func() { close(posted) }) // Before running
+	s, done := runTestApp(t, app)
+	wait(t, posted, "the function posted before running")
+
+	// Functions posted from other goroutines run on the App's goroutine, each
+	// followed by a redraw
+	var order []int
+	var posting sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		i := i
+		posting.Add(1)
+		go func() {
+			defer posting.Done()
+			if err := app.Post(func() { order = append(order, i) }); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	posting.Wait()
+	drawn := make(chan string, 1)
+	_ = app.Post(func() { label.Text = "after" })
+	_ = app.Post(func() { drawn <- rowText(s, 0)[:5] })
+	if got := <-drawn; got != "after" {
+		t.Errorf("screen shows %q after changing the label", got)
+	}
+
+	_ = app.Post(func() { app.Running = false })
+	wait(t, done, "the App to stop")
+	if len(order) != 3 {
+		t.Errorf("%d of 3 posted functions ran", len(order))
+	}
+	if err := app.Post(func() {}); err != nil {
+		t.Errorf("posting after the App stopped: %v", err)
+	}
+}
+
+func TestAppAfterFunc(t *testing.T) {
+	app := &App{MainWidget: &Label{}}
+	_, done := runTestApp(t, app)
+
+	stopped := app.AfterFunc(time.Millisecond, func() { t.Error("a stopped timer fired") })
+	stopped.Stop()
+	start := time.Now()
+	fired := make(chan struct{})
+	app.AfterFunc(20*time.Millisecond, func() {
+		if since := time.Since(start); since < 20*time.Millisecond {
+			t.Errorf("fired after %v", since)
+		}
+		close(fired)
+	})
+	wait(t, fired, "AfterFunc")
+
+	ticks := 0
+	ticked := make(chan struct{})
+	stops := make(chan func(), 1)
+	stops <- app.Every(time.Millisecond, func() {
+		ticks++
+		if ticks == 3 {
+			(<-stops)() // Ticks posted already must not run
+			close(ticked)
+		}
+	})
+	wait(t, ticked, "Every")
+
+	time.Sleep(10 * time.Millisecond)
+	_ = app.Post(func() { app.Running = false })
+	wait(t, done, "the App to stop")
+	if ticks != 3 {
+		t.Errorf("ticked %d times after stopping at 3", ticks)
+	}
+}
+
+func TestAppHandleEvent(t *testing.T) {
+	app := &App{}
+	if app.HandleEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)) {
+		t.Error("handled a key event")
+	}
+	called := false
+	if !app.HandleEvent(&eventFunc{time.Now(), func() { called = true }}) || !called {
+		t.Error("did not call a posted function")
+	}
+	if _, all := app.takeDamage(); !all {
+		t.Error("did not redraw after a posted function")
+	}
+}
@@ -11,6 +11,7 @@ type BoxDecoration struct {
 	TL, TR, BR, BL rune // Clockwise corners
 	JointT, JointR rune // Joints (for menus and other divided boxes)
 	JointB, JointL rune
+	Cross          rune // Joint of all four sides
 	Style          tcell.Style
 }
 
@@ -40,12 +41,18 @@ var DefaultBoxDecoration = BoxDecoration{
 	JointR: '┤',
 	JointB: '┴',
 	JointL: '├',
+	Cross:  '┼',
 	Style:  tcell.StyleDefault,
 }
 
+// GetChildRect returns the rect inside the sides of the Box.
+func (b *Box) GetChildRect(currentRect Rect) Rect {
+	return Rect{currentRect.X + 1, currentRect.Y + 1, Max(currentRect.W-2, 0), Max(currentRect.H-2, 0)}
+}
+
 func (b *Box) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
 	if b.Child != nil {
-		return b.Child.HandleMouse(currentRect, ev)
+		return b.Child.HandleMouse(b.GetChildRect(currentRect), ev)
 	}
 	return false
 }
@@ -63,6 +70,21 @@ func (b *Box) SetFocused(v bool) {
 	}
 }
 
+func (b *Box) FocusRect(rect Rect) (Rect, bool) {
+	if b.Child == nil {
+		return Rect{}, false
+	}
+	return focusRectOf(b.Child, b.GetChildRect(rect))
+}
+
+// TakeDamage reports the damage of the Child.
+func (b *Box) TakeDamage() ([]Rect, bool) {
+	if b.Child == nil {
+		return nil, false
+	}
+	return damageOf(b.Child)
+}
+
 func (b *Box) DisplaySize(boundsW, boundsH int) (w, h int) {
 	if b.Child != nil {
 		childW, childH := b.Child.DisplaySize(boundsW-2, boundsH-2)
@@ -85,6 +107,7 @@ func (b *Box) Draw(rect Rect, s tcell.Screen) {
 	DrawBox(rect, decoration, s)
 
 	if b.Child != nil {
-		b.Child.Draw(Rect{rect.X + 1, rect.Y + 1, rect.W - 1, rect.H - 1}, s)
+		childRect := b.GetChildRect(rect)
+		b.Child.Draw(childRect, NewClipScreen(s, childRect))
 	}
 }
@@ -0,0 +1,171 @@
+package buffer
+
+import "unicode/utf8"
+
+// MatchingBracket finds the bracket paired with the rune under the Cursor. If
+// the rune is an opening bracket, the buffer is searched forward, otherwise if
+// it is a closing bracket, the buffer is searched backward. Returns the line
+// and column of the matching bracket, and whether one was found.
+//
+// The Highlighter is optional. If one is provided, its Language supplies the
+// bracket pairs, and brackets inside strings or comments are skipped. Only
+// lines the Highlighter has matched are known, so be sure the lines between the
+// brackets are up to date.
+func (c *Cursor) MatchingBracket(h *Highlighter) (line, col int, ok bool) {
+	brackets := DefaultBrackets
+	if h != nil && h.Language != nil && h.Language.Brackets != "" {
+		brackets = h.Language.Brackets
+	}
+
+	runes := lineRunes(c.buffer, c.Line)
+	if c.Col < 0 || c.Col >= len(runes) {
+		return 0, 0, false
+	}
+	r := runes[c.Col]
+	if h != nil && isSkippedSyntax(h.SyntaxAt(c.Line, c.Col)) {
+		return 0, 0, false
+	}
+
+	if closing, isOpen := closingBracket(brackets, r); isOpen {
+		return c.searchBracket(h, r, closing, 1)
+	} else if opening, isClose := openingBracket(brackets, r); isClose {
+		return c.searchBracket(h, r, opening, -1)
+	}
+	return 0, 0, false
+}
+
+// searchBracket walks from the Cursor in the direction dir (1 or -1) for the
+// rune target, counting nested occurrences of the rune start.
+func (c *Cursor) searchBracket(h *Highlighter, start, target rune, dir int) (int, int, bool) {
+	syntax := syntaxCache{h: h, line: -1}
+	depth := 0
+	lines := c.buffer.Lines()
+	for line := c.Line; line >= 0 && line < lines; line += dir {
+		runes := lineRunes(c.buffer, line)
+		col := 0
+		if dir < 0 {
+			col = len(runes) - 1
+		}
+		if line == c.Line {
+			col = c.Col + dir // Begin after the bracket under the Cursor
+		}
+		for ; col >= 0 && col < len(runes); col += dir {
+			if r := runes[col]; r != start && r != target {
+				continue
+			}
+			if h != nil && isSkippedSyntax(syntax.at(line, col)) {
+				continue
+			}
+			if runes[col] == start {
+				depth++
+			} else if depth == 0 {
+				return line, col, true
+			} else {
+				depth--
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// A syntaxCache answers SyntaxAt for many positions of a search, without
+// rescanning the matches of every line above each position. Matches spanning
+// multiple lines are collected once, and the matches covering a line are kept
+// until a position on another line is asked for.
+type syntaxCache struct {
+	h         *Highlighter
+	scanned   int     // Lines before this have had their multiline matches collected
+	multiline []span  // Matches ending after the line they start on
+	line      int     // Line of covering, or -1
+	covering  []Match // Matches that may cover a column of line, in SyntaxAt order
+	carried   int     // Number of covering that start before line
+}
+
+// A span is a Match and the line it starts on.
+type span struct {
+	line  int
+	match Match
+}
+
+// at returns the same as Highlighter.SyntaxAt(line, col).
+func (s *syntaxCache) at(line, col int) Syntax {
+	if line != s.line {
+		s.cover(line)
+	}
+	syntax := Default
+	for i, match := range s.covering {
+		if i >= s.carried && match.Col > col {
+			continue // Starts after the position
+		}
+		if match.EndLine > line || match.EndCol >= col {
+			syntax = match.Syntax
+		}
+	}
+	return syntax
+}
+
+// cover collects the matches which could cover a column of the line.
+func (s *syntaxCache) cover(line int) {
+	matches := s.h.lineMatches
+	for ; s.scanned < line && s.scanned < len(matches); s.scanned++ {
+		for _, match := range matches[s.scanned] {
+			if match.EndLine > s.scanned {
+				s.multiline = append(s.multiline, span{s.scanned, match})
+			}
+		}
+	}
+
+	s.line = line
+	s.covering = s.covering[:0]
+	for _, v := range s.multiline {
+		if v.line < line && v.match.EndLine >= line {
+			s.covering = append(s.covering, v.match)
+		}
+	}
+	s.carried = len(s.covering)
+	if line < len(matches) {
+		s.covering = append(s.covering, matches[line]...)
+	}
+}
+
+// isSkippedSyntax returns true for syntax in which brackets carry no meaning.
+func isSkippedSyntax(s Syntax) bool {
+	return s == String || s == Comment || s == DocComment
+}
+
+// bracketPair returns the bracket paired with r in brackets, and whether r is
+// the opening bracket of the pair. The brackets string is a sequence of pairs,
+// like "()[]".
+func bracketPair(brackets string, r rune) (pair rune, opening, ok bool) {
+	for len(brackets) > 0 {
+		open, size := utf8.DecodeRuneInString(brackets)
+		closing, closingSize := utf8.DecodeRuneInString(brackets[size:])
+		switch r {
+		case open:
+			return closing, true, true
+		case closing:
+			return open, false, true
+		}
+		brackets = brackets[size+closingSize:]
+	}
+	return 0, false, false
+}
+
+// closingBracket returns the closing bracket paired with r, if r is an opening
+// bracket in brackets.
+func closingBracket(brackets string, r rune) (rune, bool) {
+	pair, opening, ok := bracketPair(brackets, r)
+	return pair, ok && opening
+}
+
+// openingBracket returns the opening bracket paired with r, if r is a closing
+// bracket in brackets.
+func openingBracket(brackets string, r rune) (rune, bool) {
+	pair, opening, ok := bracketPair(brackets, r)
+	return pair, ok && !opening
+}
+
+func lineRunes(buffer Buffer, line int) []rune {
+	bytes, _ := buffer.Line(line, false)
+	return []rune(string(bytes))
+}
@@ -0,0 +1,95 @@
+package buffer
+
+import (
+	"regexp"
+	"testing"
+)
+
+var bracketLang = &Language{
+	Rules: map[*RegexpRegion]Syntax{
+		{Start: regexp.MustCompile(`"[^"\n]*"`)}:     String,
+		{Start: regexp.MustCompile(`(?s)/\*.*?\*/`)}: Comment,
+	},
+	Brackets:       "(){}[]",
+	IndentPattern:  regexp.MustCompile(`[{(\[]\s*$`),
+	OutdentPattern: regexp.MustCompile(`^\s*[})\]]`),
+}
+
+const bracketText = `f(a, ")", b[0]) {
+	/* } (
+	*/ g("{", x)
+}`
+
+func TestMatchingBracket(t *testing.T) {
+	buf := NewRopeBuffer([]byte(bracketText))
+	h := NewHighlighter(buf, bracketLang, nil)
+	h.UpdateLines(0, buf.Lines()-1)
+
+	tests := []struct {
+		h                 *Highlighter
+		line, col         int
+		wantLine, wantCol int
+		wantOk            bool
+	}{
+		{h, 0, 1, 0, 14, true},   // ( skips the ) inside a string
+		{h, 0, 14, 0, 1, true},   // ) backward
+		{h, 0, 11, 0, 13, true},  // [
+		{h, 0, 16, 3, 0, true},   // { across lines, skipping the comment and string
+		{h, 3, 0, 0, 16, true},   // } backward
+		{h, 2, 5, 2, 12, true},   // ( after a multiline comment
+		{h, 1, 4, 0, 0, false},   // } inside a comment
+		{h, 0, 6, 0, 0, false},   // ) inside a string
+		{h, 0, 2, 0, 0, false},   // Not a bracket
+		{nil, 0, 1, 0, 6, true},  // Without a Highlighter, strings are not skipped
+		{nil, 0, 16, 1, 4, true}, // Nor are comments
+	}
+	for _, test := range tests {
+		c := NewCursor(buf)
+		c.Line, c.Col = test.line, test.col
+		line, col, ok := c.MatchingBracket(test.h)
+		if line != test.wantLine || col != test.wantCol || ok != test.wantOk {
+			t.Errorf("bracket at %d, %d matched %d, %d, %v; expected %d, %d, %v (highlighter %v)",
+				test.line, test.col, line, col, ok, test.wantLine, test.wantCol, test.wantOk, test.h != nil)
+		}
+	}
+}
+
+func TestIndentForLine(t *testing.T) {
+	buf := NewRopeBuffer([]byte("func f() {\n\tif x {\nreturn\n}\n\t}\n\ty"))
+	tests := []struct {
+		line int
+		lang *Language
+		unit string
+		want string
+	}{
+		{0, bracketLang, "", ""},
+		{1, bracketLang, "", "\t"},   // After an opening brace
+		{2, bracketLang, "", "\t\t"}, // Indents past the previous line
+		{2, bracketLang, "  ", "\t  "},
+		{3, bracketLang, "", ""},   // A closing brace outdents from the previous line
+		{5, bracketLang, "", "\t"}, // The previous line does not open a block
+		{2, nil, "", "\t"},         // Without a Language, the previous indentation
+	}
+	for _, test := range tests {
+		if got := IndentForLine(buf, test.line, test.lang, test.unit); got != test.want {
+			t.Errorf("IndentForLine(%d, %q) = %q, expected %q", test.line, test.unit, got, test.want)
+		}
+	}
+}
+
+func TestOutdent(t *testing.T) {
+	tests := []struct {
+		indent, unit, want string
+	}{
+		{"\t\t", "\t", "\t"},
+		{"        ", "    ", "    "},
+		{"\t  ", "    ", "\t "}, // Not ending with the unit; removes one column
+		{"\t", "", ""},
+		{"", "\t", ""},
+	}
+	for _, test := range tests {
+		if got := Outdent(test.indent, test.unit); got != test.want {
+			t.Errorf("Outdent(%q, %q) = %q, expected %q", test.indent, test.unit, got, test.want)
+		}
+	}
+}
@@ -32,11 +32,15 @@ func NewRegion(in Buffer) Region {
 // automatically updated when the buffer has text prepended or appended -- one
 // should register the Cursor with the Buffer's function `RegisterCursor()`
 // which makes the Cursor "anchored" to the Buffers contents when they change.
+//
+// If a Cursor has a Folding, then Up and Down skip lines hidden by collapsed
+// folds.
 type Cursor struct {
 	buffer  Buffer
 	prevCol int
 	Line    int
 	Col     int
+	Folding *Folding // Optional
 }
 
 func NewCursor(in Buffer) *Cursor {
@@ -70,7 +74,11 @@ func (c *Cursor) Up() {
 	if c.Line == 0 { // If the cursor is at the first line...
 		c.Line, c.Col = 0, 0 // Go to beginning
 	} else {
-		c.Line, c.Col = c.buffer.ClampLineCol(c.Line-1, c.Col)
+		line := c.Line - 1
+		if c.Folding != nil {
+			line = c.Folding.PrevVisibleLine(c.Line)
+		}
+		c.Line, c.Col = c.buffer.ClampLineCol(line, c.Col)
 	}
 }
 
@@ -78,7 +86,15 @@ func (c *Cursor) Down() {
 	if c.Line == c.buffer.Lines()-1 { // If the cursor is at the last line...
 		c.Line, c.Col = c.buffer.ClampLineCol(c.Line, math.MaxInt32) // Go to end of current line
 	} else {
-		c.Line, c.Col = c.buffer.ClampLineCol(c.Line+1, c.Col)
+		line := c.Line + 1
+		if c.Folding != nil {
+			if line = c.Folding.NextVisibleLine(c.Line); line == c.Line {
+				// Every line below is folded, so go to end of current line
+				c.Line, c.Col = c.buffer.ClampLineCol(c.Line, math.MaxInt32)
+				return
+			}
+		}
+		c.Line, c.Col = c.buffer.ClampLineCol(line, c.Col)
 	}
 }
 
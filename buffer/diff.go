@@ -0,0 +1,257 @@
+package buffer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// A Hunk is a contiguous region of lines that differs between two buffers. The
+// ALen lines of buffer A starting at AStart were replaced by the BLen lines of
+// buffer B starting at BStart. Lines start at zero. If ALen is zero, the lines
+// were only inserted, and AStart is the line of A they were inserted before.
+// Likewise, if BLen is zero, the lines were only deleted.
+type Hunk struct {
+	AStart, ALen int
+	BStart, BLen int
+}
+
+// IsInsert returns true if the Hunk only adds lines.
+func (h Hunk) IsInsert() bool {
+	return h.ALen == 0
+}
+
+// IsDelete returns true if the Hunk only removes lines.
+func (h Hunk) IsDelete() bool {
+	return h.BLen == 0
+}
+
+// diffLine is a line of a buffer prepared for comparison.
+type diffLine struct {
+	text    string
+	noDelim bool // True if this is the final line and it has no delimiter
+}
+
+// readDiffLines reads every line of the buffer through Buffer.Line. The empty
+// line following a final delimiter is not included, so a buffer ending with a
+// delimiter is distinct from one that does not.
+func readDiffLines(buffer Buffer) []diffLine {
+	count := buffer.Lines()
+	lines := make([]diffLine, 0, count)
+	for i := 0; i < count; i++ {
+		bytes, _ := buffer.Line(i, false)
+		if i == count-1 {
+			if len(bytes) == 0 {
+				break // Only exists because the previous line has a delimiter
+			}
+			lines = append(lines, diffLine{string(bytes), true})
+		} else {
+			lines = append(lines, diffLine{string(bytes), false})
+		}
+	}
+	return lines
+}
+
+// Diff compares the lines of buffer a to the lines of buffer b using the Myers
+// algorithm, and returns the regions that differ in ascending order. Lines are
+// compared without their delimiters, so buffers differing only in line
+// delimiter style are considered equal. An empty result means the buffers are
+// equal.
+func Diff(a, b Buffer) []Hunk {
+	return diffLines(readDiffLines(a), readDiffLines(b))
+}
+
+func diffLines(a, b []diffLine) []Hunk {
+	// Skip the common prefix and suffix, which are typically most of the lines
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	var hunks []Hunk
+	var current *Hunk
+	x, y := 0, 0
+	for _, op := range myers(a, b) {
+		switch op {
+		case diffEqual:
+			if current != nil {
+				hunks = append(hunks, *current)
+				current = nil
+			}
+			x, y = x+1, y+1
+			continue
+		}
+		if current == nil {
+			current = &Hunk{AStart: prefix + x, BStart: prefix + y}
+		}
+		if op == diffDelete {
+			current.ALen++
+			x++
+		} else {
+			current.BLen++
+			y++
+		}
+	}
+	if current != nil {
+		hunks = append(hunks, *current)
+	}
+	return hunks
+}
+
+type diffOp uint8
+
+const (
+	diffEqual diffOp = iota
+	diffDelete
+	diffInsert
+)
+
+// myers returns the shortest edit script transforming a into b. Deletions are
+// ordered before insertions within each changed region.
+func myers(a, b []diffLine) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+	offset := max
+	v := make([]int, 2*max+2)
+	var trace [][]int // The diagonals -d to d of v after each step d
+
+	// Find the length of the shortest edit script, keeping the diagonals
+	// reached by each step so the path can be walked back afterward. A step d
+	// only reaches diagonals -d to d, so the trace grows with the square of the
+	// number of edits rather than the length of the buffers.
+	var d int
+search:
+	for d = 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Move down (insertion)
+			} else {
+				x = v[offset+k-1] + 1 // Move right (deletion)
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1 // Follow the diagonal
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+
+	// Walk back from the end to build the script in reverse
+	ops := make([]diffOp, 0, max)
+	x, y := n, m
+	for ; d > 0; d-- {
+		v := trace[d-1] // Diagonal k is at index k+d-1
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[k-1+d-1] < v[k+1+d-1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+d-1]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffEqual)
+			x, y = x-1, y-1
+		}
+		if x == prevX {
+			ops = append(ops, diffInsert)
+		} else {
+			ops = append(ops, diffDelete)
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		ops = append(ops, diffEqual)
+		x, y = x-1, y-1
+	}
+
+	// Reverse into forward order
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// WriteUnifiedDiff writes the differences between buffers a and b to w in the
+// unified diff format, as produced by `diff -u`. The names are used in the
+// header of the patch. The hunks must be the result of Diff(a, b). Context is
+// the number of unchanged lines to show around each change, typically 3.
+func WriteUnifiedDiff(w io.Writer, aName, bName string, a, b Buffer, hunks []Hunk, context int) error {
+	if len(hunks) == 0 {
+		return nil
+	}
+	aLines, bLines := readDiffLines(a), readDiffLines(b)
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "--- %s\n+++ %s\n", aName, bName)
+
+	for i := 0; i < len(hunks); {
+		// Group hunks whose context would overlap into a single section
+		j := i + 1
+		for j < len(hunks) && hunks[j].AStart-(hunks[j-1].AStart+hunks[j-1].ALen) <= 2*context {
+			j++
+		}
+		first, last := hunks[i], hunks[j-1]
+
+		aStart := Max(first.AStart-context, 0)
+		aEnd := Min(last.AStart+last.ALen+context, len(aLines))
+		bStart := first.BStart - (first.AStart - aStart)
+		bEnd := last.BStart + last.BLen + (aEnd - (last.AStart + last.ALen))
+
+		fmt.Fprintf(out, "@@ -%s +%s @@\n", unifiedRange(aStart, aEnd-aStart), unifiedRange(bStart, bEnd-bStart))
+
+		aPos := aStart
+		for _, h := range hunks[i:j] {
+			for ; aPos < h.AStart; aPos++ {
+				writeUnifiedLine(out, ' ', aLines[aPos])
+			}
+			for l := h.AStart; l < h.AStart+h.ALen; l++ {
+				writeUnifiedLine(out, '-', aLines[l])
+			}
+			for l := h.BStart; l < h.BStart+h.BLen; l++ {
+				writeUnifiedLine(out, '+', bLines[l])
+			}
+			aPos = h.AStart + h.ALen
+		}
+		for ; aPos < aEnd; aPos++ {
+			writeUnifiedLine(out, ' ', aLines[aPos])
+		}
+
+		i = j
+	}
+	return out.Flush()
+}
+
+// unifiedRange formats a range of lines for a unified diff section header. The
+// first line is one-based, unless the range is empty.
+func unifiedRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	} else if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+func writeUnifiedLine(w *bufio.Writer, prefix byte, line diffLine) {
+	w.WriteByte(prefix)
+	w.WriteString(line.text)
+	w.WriteByte('\n')
+	if line.noDelim {
+		w.WriteString("\\ No newline at end of file\n")
+	}
+}
@@ -0,0 +1,139 @@
+package buffer
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Hunk
+	}{
+		{"equal", "a\nb\n", "a\nb\n", nil},
+		{"both empty", "", "", nil},
+		{"insert", "a\nc\n", "a\nb\nc\n", []Hunk{{1, 0, 1, 1}}},
+		{"delete", "a\nb\nc\n", "a\nc\n", []Hunk{{1, 1, 1, 0}}},
+		{"replace", "a\nb\nc\n", "a\nx\ny\nc\n", []Hunk{{1, 1, 1, 2}}},
+		{"a empty", "", "a\nb\n", []Hunk{{0, 0, 0, 2}}},
+		{"b empty", "a\nb\n", "", []Hunk{{0, 2, 0, 0}}},
+		{"separate", "a\nb\nc\nd\ne\n", "x\nb\nc\nd\ny\n", []Hunk{{0, 1, 0, 1}, {4, 1, 4, 1}}},
+		{"final delimiter", "a\nb", "a\nb\n", []Hunk{{1, 1, 1, 1}}},
+		{"delimiter style", "a\r\nb\r\n", "a\nb\n", nil},
+	}
+	for _, test := range tests {
+		got := Diff(NewRopeBuffer([]byte(test.a)), NewRopeBuffer([]byte(test.b)))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: hunks are %v, expected %v", test.name, got, test.want)
+		}
+	}
+}
+
+// TestDiffMinimal checks random edits are found with the fewest changed lines,
+// and that the hunks transform a into b.
+func TestDiffMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomLines := func() []diffLine {
+		lines := make([]diffLine, rng.Intn(30))
+		for i := range lines {
+			lines[i].text = string(rune('a' + rng.Intn(4)))
+		}
+		return lines
+	}
+	for i := 0; i < 200; i++ {
+		a, b := randomLines(), randomLines()
+		hunks := diffLines(a, b)
+
+		var patched []diffLine
+		aPos, changed := 0, 0
+		for _, h := range hunks {
+			patched = append(patched, a[aPos:h.AStart]...)
+			patched = append(patched, b[h.BStart:h.BStart+h.BLen]...)
+			aPos = h.AStart + h.ALen
+			changed += h.ALen + h.BLen
+		}
+		patched = append(patched, a[aPos:]...)
+		if len(patched) != len(b) || (len(b) > 0 && !reflect.DeepEqual(patched, b)) {
+			t.Fatalf("hunks %v of %v do not produce %v", hunks, a, b)
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); changed != want {
+			t.Fatalf("hunks %v of %v and %v change %d lines, expected %d", hunks, a, b, changed, want)
+		}
+	}
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b.
+func lcsLength(a, b []diffLine) int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = Max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func TestWriteUnifiedDiff(t *testing.T) {
+	lines := func(from, to int) string {
+		var s strings.Builder
+		for i := from; i <= to; i++ {
+			s.WriteString(string(rune('a'+i-1)) + "\n")
+		}
+		return s.String()
+	}
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    string
+	}{
+		{"equal", "a\n", "a\n", 3, ""},
+		{"insert", "a\nc\n", "a\nb\nc\n", 3, "@@ -1,2 +1,3 @@\n a\n+b\n c\n"},
+		{"delete", "a\nb\nc\n", "a\nc\n", 0, "@@ -2 +1,0 @@\n-b\n"},
+		{"a empty", "", "a\n", 3, "@@ -0,0 +1 @@\n+a\n"},
+		{"b empty", "a\nb\n", "", 3, "@@ -1,2 +0,0 @@\n-a\n-b\n"},
+		{
+			"context merged",
+			lines(1, 9), strings.Replace(strings.Replace(lines(1, 9), "b\n", "B\n", 1), "h\n", "H\n", 1), 3,
+			"@@ -1,9 +1,9 @@\n a\n-b\n+B\n c\n d\n e\n f\n g\n-h\n+H\n i\n",
+		},
+		{
+			"context separate",
+			lines(1, 9), strings.Replace(strings.Replace(lines(1, 9), "b\n", "B\n", 1), "h\n", "H\n", 1), 1,
+			"@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n@@ -7,3 +7,3 @@\n g\n-h\n+H\n i\n",
+		},
+		{
+			"no final delimiter",
+			"a\nb", "a\nc", 3,
+			"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+		{
+			"delimiter added",
+			"a", "a\n", 3,
+			"@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a\n",
+		},
+	}
+	for _, test := range tests {
+		a, b := NewRopeBuffer([]byte(test.a)), NewRopeBuffer([]byte(test.b))
+		var out bytes.Buffer
+		if err := WriteUnifiedDiff(&out, "a.txt", "b.txt", a, b, Diff(a, b), test.context); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		want := test.want
+		if want != "" {
+			want = "--- a.txt\n+++ b.txt\n" + want
+		}
+		if got := out.String(); got != want {
+			t.Errorf("%s: diff is\n%s\nexpected\n%s", test.name, got, want)
+		}
+	}
+}
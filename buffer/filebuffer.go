@@ -0,0 +1,679 @@
+package buffer
+
+import (
+	bytealg "bytes"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// FileChunkSize is the number of bytes a FileBuffer reads from its file at once.
+const FileChunkSize = 64 * 1024
+
+// fileChunkCacheLen is the maximum number of chunks kept in memory at once.
+const fileChunkCacheLen = 64
+
+// A FileBuffer is a read-mostly Buffer for very large files. Rather than reading
+// the entire file into memory, as NewRopeBuffer requires, the file is read in
+// chunks as they are needed. The line index is built in the background, so the
+// buffer can be viewed immediately. Functions that need a part of the index not
+// yet built will wait for it; Lines() waits for the whole index. Use
+// IndexProgress() to find how far the index has come without waiting.
+//
+// Edits never modify the file. Inserted text is kept in an overlay in memory,
+// and the document is described as pieces of the file and the overlay. Use
+// WriteTo to save the edited document, but not to the file being read from.
+type FileBuffer struct {
+	file   io.ReaderAt
+	closer io.Closer // Closed by Close(); nil if we did not open the file
+	size   int
+
+	pieces    []filePiece
+	added     []byte // Overlay of all inserted text
+	anchors   []*Cursor
+	lineDelim string
+	lines     int // Cached result of Lines(); zero if unknown
+
+	cacheMu    sync.Mutex
+	cache      map[int][]byte // Chunk index to chunk data
+	cacheOrder []int          // Chunk indexes in order of caching
+
+	indexMu     sync.Mutex
+	indexCond   *sync.Cond
+	newlineSums []int // Count of '\n' before each chunk of the file indexed so far, and after the last
+	indexDone   bool
+	closed      bool
+	err         error // First error encountered reading the file
+}
+
+// A filePiece is a span of either the file or the added overlay.
+type filePiece struct {
+	added bool // True if the piece is in the overlay, not the file
+	off   int
+	len   int
+}
+
+// OpenFileBuffer opens the file at path as a FileBuffer. The file remains open
+// until Close() is called.
+func OpenFileBuffer(path string) (*FileBuffer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	b := NewFileBuffer(f, info.Size())
+	b.closer = f
+	return b, nil
+}
+
+// NewFileBuffer makes a FileBuffer reading size bytes from the ReaderAt. The
+// contents must not change while the FileBuffer is in use. Close() should be
+// called to stop indexing when the FileBuffer is no longer needed.
+func NewFileBuffer(r io.ReaderAt, size int64) *FileBuffer {
+	b := &FileBuffer{
+		file:        r,
+		size:        int(size),
+		cache:       make(map[int][]byte),
+		newlineSums: []int{0},
+	}
+	b.indexCond = sync.NewCond(&b.indexMu)
+	if b.size > 0 {
+		b.pieces = []filePiece{{false, 0, b.size}}
+	}
+	b.lineDelim = DetectLineDelim(b.chunk(0))
+	go b.buildIndex()
+	return b
+}
+
+// Close stops indexing and closes the file if it was opened by OpenFileBuffer.
+// The FileBuffer must not be used afterward.
+func (b *FileBuffer) Close() error {
+	b.indexMu.Lock()
+	b.closed = true
+	b.indexCond.Broadcast()
+	b.indexMu.Unlock()
+	if b.closer != nil {
+		return b.closer.Close()
+	}
+	return nil
+}
+
+// Err returns the first error that occurred reading the file, if any. Parts of
+// the file that could not be read appear as zero bytes.
+func (b *FileBuffer) Err() error {
+	b.indexMu.Lock()
+	defer b.indexMu.Unlock()
+	return b.err
+}
+
+// IndexProgress returns the number of bytes of the file whose lines have been
+// indexed, and whether indexing has finished.
+func (b *FileBuffer) IndexProgress() (indexed int64, done bool) {
+	b.indexMu.Lock()
+	defer b.indexMu.Unlock()
+	indexed = int64(Min((len(b.newlineSums)-1)*FileChunkSize, b.size))
+	return indexed, b.indexDone
+}
+
+func (b *FileBuffer) buildIndex() {
+	data := make([]byte, FileChunkSize)
+	chunks := (b.size + FileChunkSize - 1) / FileChunkSize
+	for i := 0; i < chunks; i++ {
+		n, err := b.file.ReadAt(data[:Min(FileChunkSize, b.size-i*FileChunkSize)], int64(i*FileChunkSize))
+		count := bytealg.Count(data[:n], []byte{'\n'})
+
+		b.indexMu.Lock()
+		if err != nil && err != io.EOF && b.err == nil {
+			b.err = err
+		}
+		b.newlineSums = append(b.newlineSums, b.newlineSums[i]+count)
+		closed := b.closed
+		b.indexCond.Broadcast()
+		b.indexMu.Unlock()
+		if closed {
+			return
+		}
+	}
+	b.indexMu.Lock()
+	b.indexDone = true
+	b.indexCond.Broadcast()
+	b.indexMu.Unlock()
+}
+
+// newlinesBefore returns the number of '\n' in the chunks of the file before
+// the chunk at idx, waiting for the index to reach the chunk if necessary.
+func (b *FileBuffer) newlinesBefore(idx int) int {
+	b.indexMu.Lock()
+	for idx >= len(b.newlineSums) && !b.indexDone && !b.closed {
+		b.indexCond.Wait()
+	}
+	indexed := len(b.newlineSums) - 1
+	if idx <= indexed {
+		count := b.newlineSums[idx]
+		b.indexMu.Unlock()
+		return count
+	}
+	count := b.newlineSums[indexed]
+	b.indexMu.Unlock()
+	for i := indexed; i < idx; i++ { // Indexing was stopped
+		count += bytealg.Count(b.chunk(i), []byte{'\n'})
+	}
+	return count
+}
+
+// chunk returns the data of the chunk of the file at idx, reading it if it is
+// not cached. Do not write to the returned slice.
+func (b *FileBuffer) chunk(idx int) []byte {
+	b.cacheMu.Lock()
+	defer b.cacheMu.Unlock()
+	if data, ok := b.cache[idx]; ok {
+		return data
+	}
+
+	start := idx * FileChunkSize
+	if start >= b.size {
+		return nil
+	}
+	data := make([]byte, Min(FileChunkSize, b.size-start))
+	if _, err := b.file.ReadAt(data, int64(start)); err != nil && err != io.EOF {
+		b.indexMu.Lock()
+		if b.err == nil {
+			b.err = err
+		}
+		b.indexMu.Unlock()
+	}
+
+	if len(b.cacheOrder) >= fileChunkCacheLen { // Forget the oldest chunk
+		delete(b.cache, b.cacheOrder[0])
+		b.cacheOrder = b.cacheOrder[1:]
+	}
+	b.cache[idx] = data
+	b.cacheOrder = append(b.cacheOrder, idx)
+	return data
+}
+
+// eachFileSpan calls f with each part of the file between start and end, split
+// at chunk boundaries. Iteration stops when f returns true.
+func (b *FileBuffer) eachFileSpan(start, end int, f func(chunkIdx int, data []byte) bool) {
+	for start < end {
+		idx := start / FileChunkSize
+		chunkStart := idx * FileChunkSize
+		data := b.chunk(idx)
+		spanEnd := Min(end-chunkStart, len(data))
+		if spanEnd <= start-chunkStart || f(idx, data[start-chunkStart:spanEnd]) {
+			return
+		}
+		start = chunkStart + spanEnd
+	}
+}
+
+// eachSpan calls f with each part of the document from pos until the end,
+// along with the document position of the first byte of data. Iteration stops
+// when f returns true. Do not write to the data.
+func (b *FileBuffer) eachSpan(pos int, f func(spanPos int, data []byte) bool) {
+	piecePos := 0
+	for _, p := range b.pieces {
+		if pos >= piecePos+p.len {
+			piecePos += p.len
+			continue
+		}
+		start := Max(pos-piecePos, 0)
+		if p.added {
+			if f(piecePos+start, b.added[p.off+start:p.off+p.len]) {
+				return
+			}
+		} else {
+			stop := false
+			spanPos := piecePos + start
+			b.eachFileSpan(p.off+start, p.off+p.len, func(_ int, data []byte) bool {
+				stop = f(spanPos, data)
+				spanPos += len(data)
+				return stop
+			})
+			if stop {
+				return
+			}
+		}
+		piecePos += p.len
+	}
+}
+
+// read returns a copy of the document between positions start and end.
+func (b *FileBuffer) read(start, end int) []byte {
+	if end <= start {
+		return nil
+	}
+	data := make([]byte, 0, end-start)
+	b.eachSpan(start, func(spanPos int, span []byte) bool {
+		data = append(data, span[:Min(len(span), end-spanPos)]...)
+		return spanPos+len(span) >= end
+	})
+	return data
+}
+
+// wholeChunks returns the range of chunks of the file that are entirely
+// between start and end, which is empty if first >= last.
+func (b *FileBuffer) wholeChunks(start, end int) (first, last int) {
+	first = (start + FileChunkSize - 1) / FileChunkSize
+	last = end / FileChunkSize
+	if end == b.size && end%FileChunkSize != 0 {
+		last++ // The short chunk at the end of the file
+	}
+	return first, last
+}
+
+// scanNewlines reads the file between start and end to find the nth '\n'.
+// Returns the position after it, or -1 and the number of '\n' still to find.
+func (b *FileBuffer) scanNewlines(start, end, n int) (pos, left int) {
+	pos = -1
+	spanPos := start
+	b.eachFileSpan(start, end, func(_ int, data []byte) bool {
+		for i, c := range data {
+			if c == '\n' {
+				n--
+				if n == 0 {
+					pos = spanPos + i + 1
+					return true
+				}
+			}
+		}
+		spanPos += len(data)
+		return false
+	})
+	return pos, n
+}
+
+// readNewlines reads the file between start and end to count its '\n'.
+func (b *FileBuffer) readNewlines(start, end int) int {
+	count := 0
+	b.eachFileSpan(start, end, func(_ int, data []byte) bool {
+		count += bytealg.Count(data, []byte{'\n'})
+		return false
+	})
+	return count
+}
+
+// fileNewlines counts the '\n' in the file between start and end. The line
+// index is used for whole chunks, so only the chunks at the edges are read.
+func (b *FileBuffer) fileNewlines(start, end int) int {
+	first, last := b.wholeChunks(start, end)
+	if first >= last {
+		return b.readNewlines(start, end)
+	}
+	return b.readNewlines(start, first*FileChunkSize) +
+		b.newlinesBefore(last) - b.newlinesBefore(first) +
+		b.readNewlines(Min(last*FileChunkSize, b.size), end)
+}
+
+// fileLineStart returns the position after the nth '\n' in the file between
+// start and end, or -1 and the number of '\n' still to find if there are
+// fewer. The line index is used to skip to the chunk with the '\n'.
+func (b *FileBuffer) fileLineStart(start, end, n int) (pos, left int) {
+	first, last := b.wholeChunks(start, end)
+	if first < last {
+		pos, left := b.scanNewlines(start, first*FileChunkSize, n)
+		if pos >= 0 {
+			return pos, 0
+		}
+		base := b.newlinesBefore(first)
+		k := b.chunkWithNewline(first, last, base+left)
+		n = left - (b.newlinesBefore(k) - base)
+		start = Min(k*FileChunkSize, b.size)
+	}
+	return b.scanNewlines(start, end, n)
+}
+
+// chunkWithNewline returns the chunk between first and last containing the
+// nth '\n' of the file, or last if it is not before last. Only the chunks
+// indexed so far are searched, and the index is waited on one chunk at a time
+// when they do not contain it, so lines near the start of a file are found
+// before indexing finishes.
+func (b *FileBuffer) chunkWithNewline(first, last, n int) int {
+	for first < last {
+		b.indexMu.Lock()
+		indexed := len(b.newlineSums) - 1
+		b.indexMu.Unlock()
+		end := Clamp(indexed, first+1, last) // At least one chunk, even if we must wait for it
+
+		k := first + sort.Search(end-first, func(i int) bool {
+			return b.newlinesBefore(first+i+1) >= n
+		})
+		if k < end {
+			return k
+		}
+		first = end
+	}
+	return last
+}
+
+// pieceNewlines counts the '\n' in the piece.
+func (b *FileBuffer) pieceNewlines(p filePiece) int {
+	if p.added {
+		return bytealg.Count(b.added[p.off:p.off+p.len], []byte{'\n'})
+	}
+	return b.fileNewlines(p.off, p.off+p.len)
+}
+
+// lineStartPos returns the position of the first byte of the line. Panics if
+// there are not enough lines in the buffer, like RopeBuffer.
+func (b *FileBuffer) lineStartPos(line int) int {
+	if line <= 0 {
+		return 0
+	}
+	piecePos := 0
+	for _, p := range b.pieces {
+		// Search each piece rather than counting its lines first, which would
+		// wait for the index of the whole file
+		if !p.added {
+			pos, left := b.fileLineStart(p.off, p.off+p.len, line)
+			if pos >= 0 {
+				return piecePos + pos - p.off
+			}
+			line = left
+		} else {
+			for i, c := range b.added[p.off : p.off+p.len] {
+				if c == '\n' {
+					line--
+					if line == 0 {
+						return piecePos + i + 1
+					}
+				}
+			}
+		}
+		piecePos += p.len
+	}
+	panic("not enough lines in buffer to reach position")
+}
+
+// lineEndPos returns the position after the last byte of the line, including
+// its delimiter.
+func (b *FileBuffer) lineEndPos(start int) int {
+	end := b.Len()
+	b.eachSpan(start, func(spanPos int, data []byte) bool {
+		if i := bytealg.IndexByte(data, '\n'); i != -1 {
+			end = spanPos + i + 1
+			return true
+		}
+		return false
+	})
+	return end
+}
+
+func (b *FileBuffer) Line(line int, delim bool) (bytes []byte, hasDelim bool) {
+	start := b.lineStartPos(line)
+	bytes = b.read(start, b.lineEndPos(start))
+	if bytealg.HasSuffix(bytes, []byte(b.lineDelim)) {
+		if !delim {
+			return bytes[:len(bytes)-len(b.lineDelim)], false
+		}
+		return bytes, true
+	} else if n := len(bytes); n > 0 && bytes[n-1] == '\n' {
+		if !delim {
+			return bytes[:n-1], false // Delimiter differs from the one set
+		}
+		return bytes, true
+	}
+	return bytes, false
+}
+
+func (b *FileBuffer) Slice(startLine, startCol, endLine, endCol int) []byte {
+	return b.read(b.LineColToPos(startLine, startCol), b.posAfter(endLine, endCol))
+}
+
+func (b *FileBuffer) RuneAtPos(pos int) (r rune, size int) {
+	if pos < 0 || pos >= b.Len() {
+		return 0, 0
+	}
+	return utf8.DecodeRune(b.read(pos, Min(pos+utf8.UTFMax, b.Len())))
+}
+
+func (b *FileBuffer) EachRuneFromPos(pos int, f func(pos int, r rune) bool) {
+	var partial []byte // Bytes of a rune split between two spans
+	b.eachSpan(pos, func(spanPos int, data []byte) bool {
+		if len(partial) > 0 {
+			spanPos -= len(partial)
+			data = append(partial, data...)
+			partial = nil
+		}
+		for i := 0; i < len(data); {
+			if !utf8.FullRune(data[i:]) {
+				partial = append([]byte(nil), data[i:]...)
+				return false
+			}
+			r, size := utf8.DecodeRune(data[i:])
+			if f(spanPos+i, r) {
+				return true
+			}
+			i += size
+		}
+		return false
+	})
+	if len(partial) > 0 { // Invalid UTF-8 at the end of the buffer
+		r, _ := utf8.DecodeRune(partial)
+		f(b.Len()-len(partial), r)
+	}
+}
+
+func (b *FileBuffer) Bytes() []byte {
+	return b.read(0, b.Len())
+}
+
+func (b *FileBuffer) Insert(line, col int, value []byte) {
+	if len(value) == 0 {
+		return
+	}
+	pos := b.LineColToPos(line, col)
+	anchors := b.anchorPositions()
+
+	p := filePiece{true, len(b.added), len(value)}
+	b.added = append(b.added, value...)
+	idx := b.splitPieceAt(pos)
+	b.pieces = append(b.pieces, filePiece{})
+	copy(b.pieces[idx+1:], b.pieces[idx:])
+	b.pieces[idx] = p
+	b.lines = 0
+
+	for i, anchorPos := range anchors {
+		if anchorPos >= pos {
+			anchors[i] += len(value)
+		}
+	}
+	b.restoreAnchors(anchors)
+}
+
+func (b *FileBuffer) Remove(startLine, startCol, endLine, endCol int) {
+	start := b.LineColToPos(startLine, startCol)
+	end := b.posAfter(endLine, endCol)
+	if start >= end {
+		return
+	}
+	anchors := b.anchorPositions()
+
+	first := b.splitPieceAt(start)
+	last := b.splitPieceAt(end)
+	b.pieces = append(b.pieces[:first], b.pieces[last:]...)
+	b.lines = 0
+
+	for i, anchorPos := range anchors {
+		if anchorPos >= end {
+			anchors[i] -= end - start
+		} else if anchorPos > start {
+			anchors[i] = start // Anchor was within the removed range
+		}
+	}
+	b.restoreAnchors(anchors)
+}
+
+// splitPieceAt makes pos fall on a boundary between pieces, and returns the
+// index of the piece beginning at pos, which can be len(b.pieces).
+func (b *FileBuffer) splitPieceAt(pos int) int {
+	piecePos := 0
+	for i, p := range b.pieces {
+		if pos == piecePos {
+			return i
+		}
+		if pos < piecePos+p.len {
+			left := filePiece{p.added, p.off, pos - piecePos}
+			right := filePiece{p.added, p.off + left.len, p.len - left.len}
+			b.pieces = append(b.pieces, filePiece{})
+			copy(b.pieces[i+2:], b.pieces[i+1:])
+			b.pieces[i], b.pieces[i+1] = left, right
+			return i + 1
+		}
+		piecePos += p.len
+	}
+	return len(b.pieces)
+}
+
+func (b *FileBuffer) Count(startLine, startCol, endLine, endCol int, sequence []byte) int {
+	startPos := b.LineColToPos(startLine, startCol)
+	return bytealg.Count(b.read(startPos, b.posAfter(endLine, endCol)), sequence)
+}
+
+// posAfter returns the position after the last byte of the rune at line, col,
+// for converting inclusive ranges to positions. The result is clamped to Len().
+func (b *FileBuffer) posAfter(line, col int) int {
+	pos := b.LineColToPos(line, col)
+	if _, size := b.RuneAtPos(pos); size > 0 {
+		return pos + size
+	}
+	return Min(pos+1, b.Len())
+}
+
+func (b *FileBuffer) Len() int {
+	length := 0
+	for _, p := range b.pieces {
+		length += p.len
+	}
+	return length
+}
+
+// Lines waits for the line index to be complete if the count is not cached.
+func (b *FileBuffer) Lines() int {
+	if b.lines == 0 {
+		newlines := 0
+		for _, p := range b.pieces {
+			newlines += b.pieceNewlines(p)
+		}
+		b.lines = newlines + 1
+	}
+	return b.lines
+}
+
+func (b *FileBuffer) LineDelimiter() string {
+	return b.lineDelim
+}
+
+func (b *FileBuffer) SetLineDelimiter(delim string) {
+	b.lineDelim = delim
+}
+
+func (b *FileBuffer) LineHasDelimiter(line int) bool {
+	_, hasDelim := b.Line(line, true)
+	return hasDelim
+}
+
+func (b *FileBuffer) RunesInLine(line int, delim bool) (runes int, hasDelim bool) {
+	bytes, hasDelim := b.Line(line, delim)
+	return utf8.RuneCount(bytes), hasDelim
+}
+
+func (b *FileBuffer) ClampLineCol(line, col int) (int, int) {
+	if line < 0 {
+		line = 0
+	} else if lines := b.Lines() - 1; line > lines {
+		line = lines
+	}
+
+	if col < 0 {
+		col = 0
+	} else if runes, _ := b.RunesInLine(line, false); col > runes {
+		col = runes
+	}
+
+	return line, col
+}
+
+func (b *FileBuffer) LineColToPos(line, col int) int {
+	pos := b.lineStartPos(line)
+	if col > 0 {
+		b.EachRuneFromPos(pos, func(rpos int, r rune) bool {
+			if col == 0 || r == '\n' {
+				pos = rpos
+				return true // Found the position of the column
+			}
+			col--
+			pos = rpos + utf8.RuneLen(r)
+			return false
+		})
+	}
+	return pos
+}
+
+func (b *FileBuffer) PosToLineCol(pos int) (line, col int) {
+	pos = Clamp(pos, 0, b.Len())
+	piecePos := 0
+	for _, p := range b.pieces {
+		if piecePos+p.len > pos { // Count only the part before pos
+			line += b.pieceNewlines(filePiece{p.added, p.off, pos - piecePos})
+			break
+		}
+		line += b.pieceNewlines(p)
+		piecePos += p.len
+	}
+	col = utf8.RuneCount(b.read(b.lineStartPos(line), pos))
+	return line, col
+}
+
+func (b *FileBuffer) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	var err error
+	b.eachSpan(0, func(_ int, data []byte) bool {
+		var n int
+		n, err = w.Write(data)
+		written += int64(n)
+		return err != nil
+	})
+	return written, err
+}
+
+// anchorPositions returns the position of each registered Cursor, so they can
+// be restored with restoreAnchors after an edit.
+func (b *FileBuffer) anchorPositions() []int {
+	positions := make([]int, len(b.anchors))
+	for i, v := range b.anchors {
+		positions[i] = b.LineColToPos(v.Line, v.Col)
+	}
+	return positions
+}
+
+func (b *FileBuffer) restoreAnchors(positions []int) {
+	for i, v := range b.anchors {
+		v.Line, v.Col = b.PosToLineCol(positions[i])
+	}
+}
+
+// RegisterCursor adds the Cursor to a slice which the Buffer uses to update
+// each Cursor based on changes that occur in the Buffer.
+func (b *FileBuffer) RegisterCursor(cursor *Cursor) {
+	if cursor == nil {
+		return
+	}
+	b.anchors = append(b.anchors, cursor)
+}
+
+// UnregisterCursor will remove the cursor from the list of watched Cursors.
+func (b *FileBuffer) UnregisterCursor(cursor *Cursor) {
+	for i, v := range b.anchors {
+		if cursor == v {
+			b.anchors = append(b.anchors[:i], b.anchors[i+1:]...)
+			return
+		}
+	}
+}
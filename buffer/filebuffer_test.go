@@ -0,0 +1,125 @@
+package buffer
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// gatedReader is a slow file: reads past the first chunk block until the gate
+// is closed.
+type gatedReader struct {
+	data []byte
+	gate chan struct{}
+}
+
+func (r *gatedReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= FileChunkSize {
+		<-r.gate
+	}
+	return bytes.NewReader(r.data).ReadAt(p, off)
+}
+
+// numberedLines returns lines "line 0" to "line n-1", each ending with a '\n'.
+func numberedLines(n int) []byte {
+	var data bytes.Buffer
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&data, "line %d\n", i)
+	}
+	return data.Bytes()
+}
+
+func TestFileBufferLazyIndex(t *testing.T) {
+	data := numberedLines(50000) // Several chunks
+	r := &gatedReader{data, make(chan struct{})}
+	b := NewFileBuffer(r, int64(len(data)))
+	defer b.Close()
+
+	line := make(chan string)
+	go func() {
+		bytes, _ := b.Line(1, false)
+		line <- string(bytes)
+	}()
+	select {
+	case got := <-line:
+		if got != "line 1" {
+			t.Errorf("line 1 is %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		close(r.gate)
+		t.Fatal("Line(1) waited for the index of the whole file")
+	}
+	if indexed, done := b.IndexProgress(); indexed != FileChunkSize || done {
+		t.Errorf("index progress is %d, %v while the file is blocked", indexed, done)
+	}
+
+	close(r.gate)
+	if lines := b.Lines(); lines != 50001 {
+		t.Errorf("%d lines, expected 50001", lines)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		indexed, done := b.IndexProgress()
+		if done {
+			if indexed != int64(len(data)) {
+				t.Errorf("indexed %d bytes of %d", indexed, len(data))
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("indexing did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got, _ := b.Line(40000, false); string(got) != "line 40000" {
+		t.Errorf("line 40000 is %q", got)
+	}
+}
+
+func TestFileBufferEdits(t *testing.T) {
+	data := numberedLines(20000)
+	b := NewFileBuffer(bytes.NewReader(data), int64(len(data)))
+	defer b.Close()
+	ref := NewRopeBuffer(append([]byte(nil), data...))
+
+	anchor := NewCursor(b)
+	anchor.Line, anchor.Col = 15000, 5
+	b.RegisterCursor(anchor)
+
+	edits := []func(buf Buffer){
+		func(buf Buffer) { buf.Insert(0, 0, []byte("first\n")) },
+		func(buf Buffer) { buf.Insert(12926, 4, []byte("-a\n-b")) }, // Near a chunk boundary
+		func(buf Buffer) { buf.Remove(100, 0, 199, 8) },             // 100 whole lines
+		func(buf Buffer) { buf.Insert(5, 2, []byte("\n")) },
+		func(buf Buffer) { buf.Remove(0, 3, 0, 5) },
+	}
+	for i, edit := range edits {
+		edit(b)
+		edit(ref)
+		if !bytes.Equal(b.Bytes(), ref.Bytes()) {
+			t.Fatalf("contents differ after edit %d", i)
+		}
+		if b.Lines() != ref.Lines() {
+			t.Errorf("%d lines after edit %d, expected %d", b.Lines(), i, ref.Lines())
+		}
+	}
+	lines := bytes.Split(ref.Bytes(), []byte{'\n'})
+	for _, line := range []int{0, 5, 6, 100, 12827, 12828, len(lines) - 1} {
+		if got, _ := b.Line(line, false); !bytes.Equal(got, lines[line]) {
+			t.Errorf("line %d is %q, expected %q", line, got, lines[line])
+		}
+	}
+
+	// The anchor was below every edit: 2 lines were added and 100 removed
+	if anchor.Line != 14902 || anchor.Col != 5 {
+		t.Errorf("anchor is at %d, %d, expected 14902, 5", anchor.Line, anchor.Col)
+	}
+	if got, _ := b.Line(anchor.Line, false); string(got) != "line 15000" {
+		t.Errorf("anchor line is %q", got)
+	}
+	b.Remove(anchor.Line, 2, anchor.Line, 7) // Around the anchor
+	if anchor.Line != 14902 || anchor.Col != 2 {
+		t.Errorf("anchor is at %d, %d after removing around it, expected 14902, 2", anchor.Line, anchor.Col)
+	}
+}
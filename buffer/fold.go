@@ -0,0 +1,335 @@
+package buffer
+
+import "sort"
+
+// DefaultBrackets are the bracket pairs used when a Language does not provide
+// its own.
+const DefaultBrackets = "(){}[]"
+
+// A FoldRange is a range of lines that can be collapsed, with inclusive bounds.
+// The StartLine is the "header" of the fold and remains visible when the fold
+// is collapsed. Every line after it, up to and including EndLine, is hidden.
+type FoldRange struct {
+	StartLine int
+	EndLine   int
+}
+
+// A Fold is a collapsible range of lines. Its Start and End are Cursors that
+// are registered with the Buffer, so a Fold moves with any edits made to the
+// Buffer. Only the Line of each Cursor is meaningful.
+type Fold struct {
+	Start     *Cursor
+	End       *Cursor
+	Collapsed bool
+}
+
+// Range returns the current lines the Fold spans.
+func (f *Fold) Range() FoldRange {
+	return FoldRange{f.Start.Line, f.End.Line}
+}
+
+// A Folding tracks collapsible ranges of a Buffer and answers which lines are
+// visible. Ranges can be computed by indentation, by bracket pairs, or be
+// supplied by the Language. Call Close() when the Folding is no longer needed
+// to unregister its anchors from the Buffer.
+type Folding struct {
+	Buffer   Buffer
+	Language *Language // Optional; used for bracket pairs and custom fold ranges
+	TabSize  int       // Number of columns a tab counts as when comparing indentation
+
+	folds []*Fold // Sorted by start line
+}
+
+func NewFolding(buffer Buffer, lang *Language) *Folding {
+	return &Folding{
+		Buffer:   buffer,
+		Language: lang,
+		TabSize:  4,
+	}
+}
+
+// Folds returns all folds sorted by their starting line. Folds that have become
+// empty from edits to the Buffer are discarded first.
+func (f *Folding) Folds() []*Fold {
+	f.prune()
+	return f.folds
+}
+
+// SetRanges replaces every fold with the provided ranges. Folds starting on the
+// same line as a previous fold retain their collapsed state. Ranges spanning
+// less than two lines are ignored.
+func (f *Folding) SetRanges(ranges []FoldRange) {
+	collapsed := make(map[int]bool)
+	for _, fold := range f.folds {
+		if fold.Collapsed {
+			collapsed[fold.Start.Line] = true
+		}
+	}
+	f.Close()
+
+	for _, r := range ranges {
+		if r.EndLine <= r.StartLine || r.StartLine < 0 || r.EndLine >= f.Buffer.Lines() {
+			continue
+		}
+		fold := &Fold{NewCursor(f.Buffer), NewCursor(f.Buffer), collapsed[r.StartLine]}
+		fold.Start.Line = r.StartLine
+		fold.End.Line = r.EndLine
+		f.Buffer.RegisterCursor(fold.Start)
+		f.Buffer.RegisterCursor(fold.End)
+		f.folds = append(f.folds, fold)
+	}
+	f.sort()
+}
+
+// ComputeFromIndent replaces the folds with ranges determined by indentation.
+// A fold begins at a line followed by lines indented further than it, and ends
+// at the last of those lines. Blank lines do not end a fold, but trailing blank
+// lines are not included in it.
+func (f *Folding) ComputeFromIndent() {
+	type open struct {
+		line, indent int
+	}
+	var ranges []FoldRange
+	var stack []open
+	lastNonBlank := -1
+
+	lines := f.Buffer.Lines()
+	for i := 0; i < lines; i++ {
+		indent, blank := f.lineIndent(i)
+		if blank {
+			continue
+		}
+		// Close every fold this line is not indented further than
+		for len(stack) > 0 && indent <= stack[len(stack)-1].indent {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			ranges = append(ranges, FoldRange{top.line, lastNonBlank})
+		}
+		stack = append(stack, open{i, indent})
+		lastNonBlank = i
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		ranges = append(ranges, FoldRange{stack[i].line, lastNonBlank})
+	}
+
+	f.SetRanges(ranges)
+}
+
+// ComputeFromBrackets replaces the folds with ranges between matching bracket
+// pairs spanning multiple lines. The line containing the closing bracket stays
+// visible. The bracket pairs come from the Language, or DefaultBrackets.
+func (f *Folding) ComputeFromBrackets() {
+	type open struct {
+		bracket rune
+		line    int
+	}
+	var ranges []FoldRange
+	var stack []open
+	brackets := f.brackets()
+
+	lines := f.Buffer.Lines()
+	for i := 0; i < lines; i++ {
+		line, _ := f.Buffer.Line(i, false)
+		for _, r := range string(line) {
+			if closing, ok := closingBracket(brackets, r); ok {
+				stack = append(stack, open{closing, i})
+			} else if len(stack) > 0 && stack[len(stack)-1].bracket == r {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if i-1 > top.line {
+					ranges = append(ranges, FoldRange{top.line, i - 1})
+				}
+			}
+		}
+	}
+
+	f.SetRanges(ranges)
+}
+
+// ComputeFromLanguage replaces the folds with ranges supplied by the Language's
+// FoldRanges function. If there is no Language or it does not supply ranges,
+// then the folds are computed by indentation.
+func (f *Folding) ComputeFromLanguage() {
+	if f.Language != nil && f.Language.FoldRanges != nil {
+		f.SetRanges(f.Language.FoldRanges(f.Buffer))
+	} else {
+		f.ComputeFromIndent()
+	}
+}
+
+// FoldAt returns the innermost fold starting at the line, or nil.
+func (f *Folding) FoldAt(line int) *Fold {
+	f.prune()
+	var found *Fold
+	for _, fold := range f.folds {
+		if fold.Start.Line == line {
+			if found == nil || fold.End.Line < found.End.Line {
+				found = fold
+			}
+		}
+	}
+	return found
+}
+
+// Toggle collapses or expands the fold starting at the line. If no fold starts
+// at the line, the innermost fold containing the line is toggled. Returns false
+// if there was no fold to toggle.
+func (f *Folding) Toggle(line int) bool {
+	fold := f.FoldAt(line)
+	if fold == nil {
+		for _, v := range f.folds {
+			if line > v.Start.Line && line <= v.End.Line {
+				if fold == nil || v.Start.Line > fold.Start.Line {
+					fold = v
+				}
+			}
+		}
+	}
+	if fold == nil {
+		return false
+	}
+	fold.Collapsed = !fold.Collapsed
+	return true
+}
+
+// FoldAll collapses every fold.
+func (f *Folding) FoldAll() {
+	for _, fold := range f.Folds() {
+		fold.Collapsed = true
+	}
+}
+
+// UnfoldAll expands every fold.
+func (f *Folding) UnfoldAll() {
+	for _, fold := range f.Folds() {
+		fold.Collapsed = false
+	}
+}
+
+// IsLineVisible returns false if the line is hidden inside a collapsed fold.
+func (f *Folding) IsLineVisible(line int) bool {
+	f.prune()
+	return f.isVisible(line)
+}
+
+// isVisible is IsLineVisible without pruning, for queries of many lines that
+// prune once.
+func (f *Folding) isVisible(line int) bool {
+	for _, fold := range f.folds {
+		if fold.Start.Line >= line {
+			break // Folds are sorted, so none after this contain the line
+		}
+		if fold.Collapsed && line <= fold.End.Line {
+			return false
+		}
+	}
+	return true
+}
+
+// VisibleLines returns the index of each line that is not hidden by a fold,
+// in ascending order.
+func (f *Folding) VisibleLines() []int {
+	f.prune()
+	lines := f.Buffer.Lines()
+	visible := make([]int, 0, lines)
+	hiddenUntil := -1 // Last line hidden by the collapsed folds started so far
+	next := 0         // Index of the first fold starting at or after the line
+	for i := 0; i < lines; i++ {
+		for ; next < len(f.folds) && f.folds[next].Start.Line < i; next++ {
+			if fold := f.folds[next]; fold.Collapsed {
+				hiddenUntil = Max(hiddenUntil, fold.End.Line)
+			}
+		}
+		if i > hiddenUntil {
+			visible = append(visible, i)
+		}
+	}
+	return visible
+}
+
+// NextVisibleLine returns the first visible line after the line, or the line
+// itself if there are none.
+func (f *Folding) NextVisibleLine(line int) int {
+	f.prune()
+	lines := f.Buffer.Lines()
+	for i := line + 1; i < lines; i++ {
+		if f.isVisible(i) {
+			return i
+		}
+	}
+	return line
+}
+
+// PrevVisibleLine returns the first visible line before the line, or the line
+// itself if there are none.
+func (f *Folding) PrevVisibleLine(line int) int {
+	f.prune()
+	for i := line - 1; i >= 0; i-- {
+		if f.isVisible(i) {
+			return i
+		}
+	}
+	return line
+}
+
+// Close removes every fold and unregisters their anchors from the Buffer.
+func (f *Folding) Close() {
+	for _, fold := range f.folds {
+		f.Buffer.UnregisterCursor(fold.Start)
+		f.Buffer.UnregisterCursor(fold.End)
+	}
+	f.folds = nil
+}
+
+// prune discards folds which no longer span multiple lines, which happens when
+// the lines between their anchors are removed.
+func (f *Folding) prune() {
+	for i := 0; i < len(f.folds); i++ {
+		if fold := f.folds[i]; fold.End.Line <= fold.Start.Line {
+			f.Buffer.UnregisterCursor(fold.Start)
+			f.Buffer.UnregisterCursor(fold.End)
+			f.folds = append(f.folds[:i], f.folds[i+1:]...)
+			i--
+		}
+	}
+	if !sort.SliceIsSorted(f.folds, f.less) { // Only when edits reorder the anchors
+		f.sort()
+	}
+}
+
+func (f *Folding) sort() {
+	sort.SliceStable(f.folds, f.less)
+}
+
+func (f *Folding) less(i, j int) bool {
+	return f.folds[i].Start.Line < f.folds[j].Start.Line
+}
+
+func (f *Folding) brackets() string {
+	if f.Language != nil && f.Language.Brackets != "" {
+		return f.Language.Brackets
+	}
+	return DefaultBrackets
+}
+
+// lineIndent returns the width of the leading whitespace of the line, counting
+// tabs as TabSize columns, and whether the line contains only whitespace.
+func (f *Folding) lineIndent(line int) (indent int, blank bool) {
+	tabSize := f.TabSize
+	if tabSize <= 0 {
+		tabSize = 4
+	}
+	bytes, _ := f.Buffer.Line(line, false)
+	for _, r := range string(bytes) {
+		switch r {
+		case ' ':
+			indent++
+		case '\t':
+			indent += tabSize
+		case '\r':
+		default:
+			return indent, false
+		}
+	}
+	return indent, true
+}
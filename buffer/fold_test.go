@@ -0,0 +1,126 @@
+package buffer
+
+import (
+	"reflect"
+	"testing"
+)
+
+const foldText = `func a() {
+	x := 1
+	if x {
+		y
+	}
+}
+
+func b() {
+	z
+}`
+
+// foldRanges returns the range of each fold.
+func foldRanges(f *Folding) []FoldRange {
+	var ranges []FoldRange
+	for _, fold := range f.Folds() {
+		ranges = append(ranges, fold.Range())
+	}
+	return ranges
+}
+
+func expectRanges(t *testing.T, f *Folding, want ...FoldRange) {
+	t.Helper()
+	if got := foldRanges(f); !reflect.DeepEqual(got, want) {
+		t.Errorf("folds are %v, expected %v", got, want)
+	}
+}
+
+func TestFoldComputeFromIndent(t *testing.T) {
+	f := NewFolding(NewRopeBuffer([]byte(foldText)), nil)
+	f.ComputeFromIndent()
+	expectRanges(t, f, FoldRange{0, 4}, FoldRange{2, 3}, FoldRange{7, 8})
+
+	// Blank lines do not end a fold, but are not included at its end
+	f = NewFolding(NewRopeBuffer([]byte("a\n  b\n\n  c\n\nd")), nil)
+	f.ComputeFromIndent()
+	expectRanges(t, f, FoldRange{0, 3})
+}
+
+func TestFoldComputeFromBrackets(t *testing.T) {
+	f := NewFolding(NewRopeBuffer([]byte(foldText)), nil)
+	f.ComputeFromBrackets()
+	expectRanges(t, f, FoldRange{0, 4}, FoldRange{2, 3}, FoldRange{7, 8})
+
+	// The closing line stays visible, and single-line pairs do not fold
+	f = NewFolding(NewRopeBuffer([]byte("call(a,\n\tb,\n\tc(d))\n<\n>")), &Language{Brackets: "()<>"})
+	f.ComputeFromBrackets()
+	expectRanges(t, f, FoldRange{0, 1})
+}
+
+func TestFoldToggle(t *testing.T) {
+	f := NewFolding(NewRopeBuffer([]byte(foldText)), nil)
+	f.ComputeFromIndent()
+	if got := f.VisibleLines(); len(got) != 10 {
+		t.Errorf("visible lines are %v with no fold collapsed", got)
+	}
+
+	if !f.Toggle(0) {
+		t.Fatal("no fold at line 0")
+	}
+	if got, want := f.VisibleLines(), []int{0, 5, 6, 7, 8, 9}; !reflect.DeepEqual(got, want) {
+		t.Errorf("visible lines are %v, expected %v", got, want)
+	}
+	if f.IsLineVisible(3) || !f.IsLineVisible(5) {
+		t.Error("line 3 should be hidden and line 5 visible")
+	}
+	if next, prev := f.NextVisibleLine(0), f.PrevVisibleLine(5); next != 5 || prev != 0 {
+		t.Errorf("next visible line is %d and previous is %d", next, prev)
+	}
+
+	if !f.Toggle(3) || !f.FoldAt(2).Collapsed { // The innermost fold containing the line
+		t.Error("toggling line 3 did not collapse the fold at line 2")
+	}
+	f.Toggle(0) // Expand, leaving the inner fold collapsed
+	if got, want := f.VisibleLines(), []int{0, 1, 2, 4, 5, 6, 7, 8, 9}; !reflect.DeepEqual(got, want) {
+		t.Errorf("visible lines are %v, expected %v", got, want)
+	}
+	if f.Toggle(6) {
+		t.Error("toggled a line outside every fold")
+	}
+
+	f.FoldAll()
+	if got, want := f.VisibleLines(), []int{0, 5, 6, 7, 9}; !reflect.DeepEqual(got, want) {
+		t.Errorf("visible lines are %v after FoldAll, expected %v", got, want)
+	}
+	f.UnfoldAll()
+	if got := f.VisibleLines(); len(got) != 10 {
+		t.Errorf("visible lines are %v after UnfoldAll", got)
+	}
+}
+
+func TestFoldAnchors(t *testing.T) {
+	buf := NewRopeBuffer([]byte(foldText))
+	f := NewFolding(buf, nil)
+	f.ComputeFromIndent()
+	f.Toggle(7)
+
+	buf.Insert(0, 0, []byte("// A\n")) // Above every fold
+	expectRanges(t, f, FoldRange{1, 5}, FoldRange{3, 4}, FoldRange{8, 9})
+	if !f.FoldAt(8).Collapsed {
+		t.Error("fold lost its collapsed state")
+	}
+
+	buf.Insert(2, 0, []byte("\tw := 0\n")) // Inside the outer fold
+	expectRanges(t, f, FoldRange{1, 6}, FoldRange{4, 5}, FoldRange{9, 10})
+
+	buf.Remove(0, 0, 0, 4) // The first line, with its delimiter
+	expectRanges(t, f, FoldRange{0, 5}, FoldRange{3, 4}, FoldRange{8, 9})
+
+	buf.Remove(3, 0, 4, 0) // Leaves the fold at line 3 one line long
+	expectRanges(t, f, FoldRange{0, 4}, FoldRange{7, 8})
+	if len(buf.anchors) != 4 {
+		t.Errorf("%d anchors are registered, expected 4", len(buf.anchors))
+	}
+
+	f.Close()
+	if len(buf.anchors) != 0 {
+		t.Errorf("%d anchors are registered after Close", len(buf.anchors))
+	}
+}
@@ -189,6 +189,25 @@ func (h *Highlighter) GetLineMatches(line int) []Match {
 	return data
 }
 
+// SyntaxAt returns the Syntax of the match covering the rune at line, col, or
+// Default if no match covers it. Matches beginning on previous lines are
+// considered, so multiline matches are found, too. Lines must be up to date,
+// see UpdateInvalidatedLines.
+func (h *Highlighter) SyntaxAt(line, col int) Syntax {
+	syntax := Default
+	for i := 0; i <= line && i < len(h.lineMatches); i++ {
+		for _, match := range h.lineMatches[i] {
+			if i == line && match.Col > col {
+				continue // Starts after the position
+			}
+			if match.EndLine > line || (match.EndLine == line && match.EndCol >= col) {
+				syntax = match.Syntax
+			}
+		}
+	}
+	return syntax
+}
+
 func (h *Highlighter) GetStyle(match Match) tcell.Style {
 	return h.Colorscheme.GetStyle(match.Syntax)
 }
@@ -0,0 +1,84 @@
+package buffer
+
+import "strings"
+
+// LineIndent returns the leading spaces and tabs of the line.
+func LineIndent(buffer Buffer, line int) string {
+	bytes, _ := buffer.Line(line, false)
+	for i, b := range bytes {
+		if b != ' ' && b != '\t' {
+			return string(bytes[:i])
+		}
+	}
+	return strings.TrimRight(string(bytes), "\r")
+}
+
+// NewLineIndent computes the indentation for a new line made by splitting the
+// line at col, like when the user presses Enter. The new line receives the
+// indentation of the line being split, plus one `unit` if the text before col
+// matches the Language's IndentPattern, and minus one `unit` if the text after
+// col matches its OutdentPattern. The Language is optional, and a unit of ""
+// defaults to a single tab.
+func NewLineIndent(buffer Buffer, line, col int, lang *Language, unit string) string {
+	if unit == "" {
+		unit = "\t"
+	}
+	indent := LineIndent(buffer, line)
+	if lang == nil {
+		return indent
+	}
+
+	runes := lineRunes(buffer, line)
+	col = Clamp(col, 0, len(runes))
+	before, after := string(runes[:col]), string(runes[col:])
+
+	if lang.IndentPattern != nil && lang.IndentPattern.MatchString(before) {
+		indent += unit
+	}
+	if lang.OutdentPattern != nil && lang.OutdentPattern.MatchString(after) {
+		indent = Outdent(indent, unit)
+	}
+	return indent
+}
+
+// IndentForLine computes the indentation a line should have based on the line
+// before it. It is useful for reindenting a line after the user types, such as
+// when a closing brace is typed at the beginning of a line. The first line has
+// no indentation. A unit of "" defaults to a single tab.
+func IndentForLine(buffer Buffer, line int, lang *Language, unit string) string {
+	if line <= 0 {
+		return ""
+	}
+	if unit == "" {
+		unit = "\t"
+	}
+	indent := LineIndent(buffer, line-1)
+	if lang == nil {
+		return indent
+	}
+
+	if lang.IndentPattern != nil {
+		prev, _ := buffer.Line(line-1, false)
+		if lang.IndentPattern.Match(prev) {
+			indent += unit
+		}
+	}
+	if lang.OutdentPattern != nil {
+		current, _ := buffer.Line(line, false)
+		if lang.OutdentPattern.Match(current) {
+			indent = Outdent(indent, unit)
+		}
+	}
+	return indent
+}
+
+// Outdent removes one unit of indentation from the end of indent. If indent
+// does not end with the unit, a single trailing tab or space is removed.
+func Outdent(indent, unit string) string {
+	if unit != "" && strings.HasSuffix(indent, unit) {
+		return indent[:len(indent)-len(unit)]
+	} else if len(indent) > 0 {
+		return indent[:len(indent)-1]
+	}
+	return indent
+}
@@ -0,0 +1,181 @@
+package buffer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const journalHeader = "dos-journal 1\n"
+
+// ErrBadJournal is returned by RecoverJournal when a file is not a journal.
+var ErrBadJournal = errors.New("not a buffer journal")
+
+// A JournalEntry is a single edit recorded in a journal. If Remove is false, the
+// entry is an insertion of Bytes at StartLine, StartCol, otherwise the entry
+// is a removal of StartLine, StartCol to EndLine, EndCol, inclusive bounds.
+type JournalEntry struct {
+	Time                time.Time
+	Remove              bool
+	StartLine, StartCol int
+	EndLine, EndCol     int
+	Bytes               []byte
+}
+
+// A Journal is a Buffer that appends every edit, with a timestamp, to a swap
+// file. If the program crashes before the document is saved, the edits can be
+// recovered with RecoverJournal by replaying them onto the original document.
+// Call Truncate after saving the document, and Close when it is closed.
+//
+// Each edit is written directly to the file, so edits survive the program
+// crashing, but the file is not synced to the disk for each edit.
+type Journal struct {
+	Buffer
+	file *os.File
+	err  error
+}
+
+// JournalPath returns the conventional path for the journal of the document at
+// path: a hidden file in the same directory, like ".notes.txt.swp".
+func JournalPath(path string) string {
+	dir, name := filepath.Split(path)
+	return filepath.Join(dir, "."+name+".swp")
+}
+
+// NewJournal wraps the Buffer so edits are recorded to a new journal at path.
+// Any existing file at the path is overwritten, so recover it first.
+func NewJournal(buffer Buffer, path string) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = file.WriteString(journalHeader); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &Journal{Buffer: buffer, file: file}, nil
+}
+
+// Err returns the first error that occurred writing to the journal. Edits to the
+// Buffer still happen when the journal cannot be written.
+func (j *Journal) Err() error {
+	return j.err
+}
+
+func (j *Journal) Insert(line, col int, bytes []byte) {
+	j.write(fmt.Sprintf("I %d %d %d %d\n", time.Now().UnixNano(), line, col, len(bytes)), bytes, true)
+	j.Buffer.Insert(line, col, bytes)
+}
+
+func (j *Journal) Remove(startLine, startCol, endLine, endCol int) {
+	j.write(fmt.Sprintf("R %d %d %d %d %d\n", time.Now().UnixNano(), startLine, startCol, endLine, endCol), nil, false)
+	j.Buffer.Remove(startLine, startCol, endLine, endCol)
+}
+
+func (j *Journal) write(header string, bytes []byte, hasBytes bool) {
+	if j.err != nil {
+		return
+	}
+	// Written at once, so a crash cannot leave an entry without its bytes
+	entry := make([]byte, 0, len(header)+len(bytes)+1)
+	entry = append(entry, header...)
+	if hasBytes {
+		entry = append(append(entry, bytes...), '\n')
+	}
+	_, j.err = j.file.Write(entry)
+}
+
+// Truncate discards every recorded edit. Call it when the document has been
+// saved cleanly, as the edits no longer need to be recovered.
+func (j *Journal) Truncate() error {
+	if err := j.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := j.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := j.file.WriteString(journalHeader)
+	j.err = err
+	return err
+}
+
+// Close closes and removes the journal file. The Buffer can still be used, but
+// further edits are not recorded.
+func (j *Journal) Close() error {
+	name := j.file.Name()
+	if err := j.file.Close(); err != nil {
+		return err
+	}
+	j.err = os.ErrClosed
+	return os.Remove(name)
+}
+
+// ReadJournal reads every complete entry from a journal. An entry cut short by a
+// crash at the end of the journal is ignored.
+func ReadJournal(r io.Reader) ([]JournalEntry, error) {
+	reader := bufio.NewReader(r)
+	header := make([]byte, len(journalHeader))
+	if _, err := io.ReadFull(reader, header); err != nil || string(header) != journalHeader {
+		return nil, ErrBadJournal
+	}
+
+	var entries []JournalEntry
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return entries, nil // Any partial line is an incomplete entry
+		} else if err != nil {
+			return entries, err
+		}
+
+		var entry JournalEntry
+		var nanos int64
+		var length int
+		switch line[0] {
+		case 'I':
+			if _, err = fmt.Sscanf(line, "I %d %d %d %d\n", &nanos, &entry.StartLine, &entry.StartCol, &length); err != nil {
+				return entries, fmt.Errorf("journal entry %d: %v", len(entries), err)
+			}
+			entry.Bytes = make([]byte, length+1) // Including trailing newline
+			if _, err = io.ReadFull(reader, entry.Bytes); err != nil {
+				return entries, nil // Incomplete entry
+			}
+			entry.Bytes = entry.Bytes[:length]
+		case 'R':
+			if _, err = fmt.Sscanf(line, "R %d %d %d %d %d\n", &nanos, &entry.StartLine, &entry.StartCol, &entry.EndLine, &entry.EndCol); err != nil {
+				return entries, fmt.Errorf("journal entry %d: %v", len(entries), err)
+			}
+			entry.Remove = true
+		default:
+			return entries, fmt.Errorf("journal entry %d: unknown kind %q", len(entries), line[0])
+		}
+		entry.Time = time.Unix(0, nanos)
+		entries = append(entries, entry)
+	}
+}
+
+// RecoverJournal replays the edits of the journal at path onto the Buffer, which
+// should hold the document as it was when the journal was created or last
+// truncated: the original file. Returns the number of edits replayed. Edits
+// read before an error occurred are still replayed.
+func RecoverJournal(buffer Buffer, path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	entries, err := ReadJournal(file)
+	for _, entry := range entries {
+		if entry.Remove {
+			buffer.Remove(entry.StartLine, entry.StartCol, entry.EndLine, entry.EndCol)
+		} else {
+			buffer.Insert(entry.StartLine, entry.StartCol, entry.Bytes)
+		}
+	}
+	return len(entries), err
+}
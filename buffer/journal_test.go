@@ -0,0 +1,102 @@
+package buffer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const journalText = "one\ntwo\nthree\n"
+
+// writeTestJournal makes edits to a journaled buffer of journalText and leaves
+// the journal as a crash would: neither truncated nor closed. Returns the path
+// of the journal and the edited contents.
+func writeTestJournal(t *testing.T) (string, []byte) {
+	t.Helper()
+	path := JournalPath(filepath.Join(t.TempDir(), "notes.txt"))
+	j, err := NewJournal(NewRopeBuffer([]byte(journalText)), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.file.Close() // Without removing the journal
+
+	j.Insert(0, 3, []byte(" 1"))
+	j.Insert(1, 0, []byte("zero\n\n"))      // Bytes spanning lines
+	j.Remove(3, 1, 3, 2)                    // "wo" of "two"
+	j.Insert(4, 5, []byte("\x00R 1 2 3\n")) // Bytes resembling an entry
+	j.Remove(0, 0, 1, 4)                    // "one 1\nzero"
+	if err := j.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return path, j.Bytes()
+}
+
+func TestRecoverJournal(t *testing.T) {
+	path, want := writeTestJournal(t)
+	buffer := NewRopeBuffer([]byte(journalText))
+	n, err := RecoverJournal(buffer, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Errorf("replayed %d edits, expected 5", n)
+	}
+	if got := buffer.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("recovered %q, expected %q", got, want)
+	}
+}
+
+func TestReadJournalIncomplete(t *testing.T) {
+	path, _ := writeTestJournal(t)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := ReadJournal(bytes.NewReader(data))
+	if err != nil || len(all) != 5 {
+		t.Fatalf("read %d entries and error %v", len(all), err)
+	}
+
+	// Every cut leaves the complete entries before it, without an error
+	for cut := len(journalHeader); cut < len(data); cut++ {
+		entries, err := ReadJournal(bytes.NewReader(data[:cut]))
+		if err != nil {
+			t.Fatalf("journal cut at %d: %v", cut, err)
+		}
+		if len(entries) > 0 && !reflect.DeepEqual(entries, all[:len(entries)]) {
+			t.Fatalf("journal cut at %d read different entries", cut)
+		}
+	}
+	if entries, _ := ReadJournal(bytes.NewReader(data[:len(data)-1])); len(entries) != 4 {
+		t.Errorf("read %d entries with the last half written, expected 4", len(entries))
+	}
+}
+
+func TestReadJournalMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		entries int
+		bad     bool // Expect ErrBadJournal
+	}{
+		{"empty", "", 0, true},
+		{"wrong header", "dos-journal 2\n", 0, true},
+		{"unknown kind", journalHeader + "R 1 0 0 0 1\nX 1 2 3\n", 1, false},
+		{"bad insert", journalHeader + "I 1 0 0 x\nabc\n", 0, false},
+		{"bad remove", journalHeader + "I 1 0 0 1\na\nR 1 0 0\n", 1, false},
+		{"blank line", journalHeader + "\n", 0, false},
+	}
+	for _, test := range tests {
+		entries, err := ReadJournal(bytes.NewReader([]byte(test.data)))
+		if len(entries) != test.entries {
+			t.Errorf("%s: read %d entries, expected %d", test.name, len(entries), test.entries)
+		}
+		if test.bad && err != ErrBadJournal {
+			t.Errorf("%s: error is %v, expected ErrBadJournal", test.name, err)
+		} else if !test.bad && (err == nil || err == ErrBadJournal) {
+			t.Errorf("%s: error is %v", test.name, err)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package buffer
 
+import "regexp"
+
 type Syntax uint8
 
 const (
@@ -19,5 +21,16 @@ type Language struct {
 	Name      string
 	Filetypes []string // .go, .c, etc.
 	Rules     map[*RegexpRegion]Syntax
+	Brackets  string // Pairs of opening and closing brackets, like "(){}[]"
+	// FoldRanges optionally supplies the collapsible ranges of a Buffer. If it
+	// is nil, a Folding computes ranges by indentation instead.
+	FoldRanges func(buffer Buffer) []FoldRange
+	// IndentPattern matches a line after which the next line is indented by one
+	// more level, like a line ending with an opening brace. Optional.
+	IndentPattern *regexp.Regexp
+	// OutdentPattern matches a line which should be indented one level less
+	// than the line before it, like a line starting with a closing brace.
+	// Optional.
+	OutdentPattern *regexp.Regexp
 	// TODO: add other language details
 }
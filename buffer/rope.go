@@ -114,8 +114,10 @@ func (b *RopeBuffer) Bytes() []byte {
 }
 
 func (b *RopeBuffer) Insert(line, col int, value []byte) {
-	b.rope.Insert(b.LineColToPos(line, col), value)
-	b.shiftAnchors(line, col, utf8.RuneCount(value))
+	pos := b.LineColToPos(line, col)
+	anchors := b.anchorPositions()
+	b.rope.Insert(pos, value)
+	b.shiftAnchors(anchors, pos, pos, len(value))
 }
 
 func (b *RopeBuffer) Remove(startLine, startCol, endLine, endCol int) {
@@ -129,11 +131,9 @@ func (b *RopeBuffer) Remove(startLine, startCol, endLine, endCol int) {
 		}
 	}
 
+	anchors := b.anchorPositions()
 	b.rope.Remove(start, end)
-	// Shift anchors within the range
-	b.shiftAnchorsRemovedRange(start, startLine, startCol, endLine, endCol)
-	// Shift anchors after the range
-	b.shiftAnchors(endLine, endCol+1, start-end-1)
+	b.shiftAnchors(anchors, start, end, start-end)
 }
 
 func (b *RopeBuffer) Count(startLine, startCol, endLine, endCol int, sequence []byte) int {
@@ -281,39 +281,37 @@ func (b *RopeBuffer) WriteTo(w io.Writer) (int64, error) {
 	return b.rope.WriteTo(w)
 }
 
-// Currently meant for the Remove function: imagine if the removed region passes through a Cursor position.
-// We want to shift the cursor to the start of the region, based upon where the cursor position is.
-func (b *RopeBuffer) shiftAnchorsRemovedRange(startPos, startLine, startCol, endLine, endCol int) {
+// anchorPositions returns the byte position of each anchor, discarding nil
+// anchors. It is called before an edit, because the line and column of an
+// anchor only locate it in the contents it was set against.
+func (b *RopeBuffer) anchorPositions() []int {
+	positions := make([]int, 0, len(b.anchors))
 	for i := 0; i < len(b.anchors); i++ {
-		v := b.anchors[i]
-		if v == nil {
+		if b.anchors[i] == nil {
 			b.removeCursorAtIdx(i)
 			i--
 			continue
 		}
-
-		if v.Line >= startLine && v.Line <= endLine {
-			// If the anchor is not within the start or end columns
-			if (v.Line == startLine && v.Col < startCol) || (v.Line == endLine && v.Col > endCol) {
-				continue
-			}
-			cursorPos := b.LineColToPos(v.Line, v.Col)
-			v.Line, v.Col = b.PosToLineCol(cursorPos + (startPos - cursorPos))
-		}
+		positions = append(positions, b.LineColToPos(b.anchors[i].Line, b.anchors[i].Col))
 	}
+	return positions
 }
 
-func (b *RopeBuffer) shiftAnchors(insertLine, insertCol, runeCount int) {
-	for i := 0; i < len(b.anchors); i++ {
-		v := b.anchors[i]
-		if v == nil {
-			b.removeCursorAtIdx(i)
-			i--
+// shiftAnchors moves the anchors after an edit replacing the bytes from start
+// to end with ones changing the length by delta. The positions are those from
+// anchorPositions before the edit. Anchors within a removed region move to its
+// start, and anchors at or after its end move by delta.
+func (b *RopeBuffer) shiftAnchors(positions []int, start, end, delta int) {
+	for i, pos := range positions {
+		if pos < start {
 			continue
 		}
-		if insertLine < v.Line || (insertLine == v.Line && insertCol <= v.Col) {
-			v.Line, v.Col = b.PosToLineCol(b.LineColToPos(v.Line, v.Col) + runeCount)
+		if pos < end {
+			pos = start
+		} else {
+			pos += delta
 		}
+		b.anchors[i].Line, b.anchors[i].Col = b.PosToLineCol(pos)
 	}
 }
 
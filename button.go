@@ -12,11 +12,13 @@ type Button struct {
 	FocusedStyle tcell.Style
 	OnPressed    func()
 	focused      bool
+	damage       damage
 }
 
 func (b *Button) Press() {
 	if b.OnPressed != nil {
 		b.OnPressed()
+		b.damage.add() // The callback could change anything
 	}
 }
 
@@ -31,17 +33,27 @@ func (b *Button) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
 }
 
 func (b *Button) HandleKey(ev *tcell.EventKey) bool {
-	if b.focused && ev.Key() == tcell.KeyEnter || ev.Rune() == ' ' {
+	if b.focused && (ev.Key() == tcell.KeyEnter || ev.Rune() == ' ') {
 		b.Press()
 		return true
 	}
 	return false
 }
 
+// TakeDamage reports the whole screen after the Button was pressed.
+func (b *Button) TakeDamage() ([]Rect, bool) {
+	return b.damage.take()
+}
+
 func (b *Button) SetFocused(v bool) {
 	b.focused = v
 }
 
+// FocusRect returns the rect of the Button if it is focused.
+func (b *Button) FocusRect(rect Rect) (Rect, bool) {
+	return rect, b.focused
+}
+
 func (b *Button) DisplaySize(boundsW, boundsH int) (w, h int) {
 	return runewidth.StringWidth(b.Text) + 4, 1
 }
@@ -36,6 +36,21 @@ func (c *Center) SetFocused(b bool) {
 	}
 }
 
+func (c *Center) FocusRect(rect Rect) (Rect, bool) {
+	if c.Child == nil {
+		return Rect{}, false
+	}
+	return focusRectOf(c.Child, c.GetChildRect(rect))
+}
+
+// TakeDamage reports the damage of the Child.
+func (c *Center) TakeDamage() ([]Rect, bool) {
+	if c.Child == nil {
+		return nil, false
+	}
+	return damageOf(c.Child)
+}
+
 func (c *Center) DisplaySize(boundsW, boundsH int) (w, h int) {
 	return boundsW, boundsH
 }
@@ -0,0 +1,63 @@
+package dos
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// intersect returns the part of both rects, which is empty if they do not
+// overlap.
+func intersect(a, b Rect) Rect {
+	x, y := Max(a.X, b.X), Max(a.Y, b.Y)
+	w := Min(a.X+a.W, b.X+b.W) - x
+	h := Min(a.Y+a.H, b.Y+b.H) - y
+	return Rect{x, y, Max(w, 0), Max(h, 0)}
+}
+
+// A ClipScreen is a tcell.Screen that draws only the cells inside its Clip
+// rect, so a container can pass it to a child that must not draw outside its
+// rect. A wide rune cut by the edge of the Clip is drawn as a space instead.
+type ClipScreen struct {
+	tcell.Screen
+	Clip Rect
+}
+
+// NewClipScreen returns a ClipScreen drawing only inside the rect of the
+// screen. If the screen is a ClipScreen, then the result draws only inside
+// both rects.
+func NewClipScreen(s tcell.Screen, rect Rect) *ClipScreen {
+	if c, ok := s.(*ClipScreen); ok {
+		return &ClipScreen{c.Screen, intersect(c.Clip, rect)}
+	}
+	return &ClipScreen{s, rect}
+}
+
+func (s *ClipScreen) SetContent(x, y int, mainc rune, combc []rune, style tcell.Style) {
+	if runewidth.RuneWidth(mainc) == 2 {
+		switch {
+		case x == s.Clip.X-1 && s.Clip.HasPoint(x+1, y):
+			// Only the right half is inside
+			s.Screen.SetContent(x+1, y, ' ', nil, style)
+			return
+		case s.Clip.HasPoint(x, y) && !s.Clip.HasPoint(x+1, y):
+			mainc, combc = ' ', nil // Only the left half is inside
+		}
+	}
+	if s.Clip.HasPoint(x, y) {
+		s.Screen.SetContent(x, y, mainc, combc, style)
+	}
+}
+
+// Fill fills only the Clip rect.
+func (s *ClipScreen) Fill(r rune, style tcell.Style) {
+	DrawRect(s.Clip, r, style, s.Screen)
+}
+
+// ShowCursor shows the cursor only if it is inside the Clip rect.
+func (s *ClipScreen) ShowCursor(x, y int) {
+	if s.Clip.HasPoint(x, y) {
+		s.Screen.ShowCursor(x, y)
+	} else {
+		s.Screen.HideCursor()
+	}
+}
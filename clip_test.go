@@ -0,0 +1,72 @@
+package dos
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// rowText returns the runes of the row of the screen, skipping the second
+// cell of wide runes.
+func rowText(s tcell.Screen, y int) string {
+	w, _ := s.Size()
+	var text []rune
+	for x := 0; x < w; {
+		r, _, _, width := s.GetContent(x, y)
+		text = append(text, r)
+		x += Max(width, 1)
+	}
+	return string(text)
+}
+
+func TestClipScreenWideRunes(t *testing.T) {
+	clip := Rect{2, 0, 4, 1} // Columns 2 to 5
+	tests := []struct {
+		x    int
+		text string
+		want string
+	}{
+		{1, "世界世", ".. 界 ...."}, // Cut at both edges
+		{0, "世界", "..界......"},  // Entirely outside, then entirely inside
+		{2, "界界", "..界界...."},
+		{4, "ab世", "....ab...."}, // Entirely outside on the right
+		{5, "世", "..... ...."},   // Only the left half is inside
+		{-1, "世世世", ".. 世....."}, // Starts left of the screen
+	}
+	for _, test := range tests {
+		s := newTestScreen(t, 10, 2)
+		s.Fill('.', tcell.StyleDefault)
+		DrawString(test.x, 0, test.text, tcell.StyleDefault, NewClipScreen(s, clip))
+		if got := rowText(s, 0); got != test.want {
+			t.Errorf("%q at %d drew %q, expected %q", test.text, test.x, got, test.want)
+		}
+		if got := rowText(s, 1); got != ".........." {
+			t.Errorf("%q at %d drew %q below the clip", test.text, test.x, got)
+		}
+	}
+}
+
+func TestNewClipScreenNested(t *testing.T) {
+	s := newTestScreen(t, 10, 2)
+	s.Fill('.', tcell.StyleDefault)
+	outer := NewClipScreen(s, Rect{2, 0, 4, 2})
+	inner := NewClipScreen(outer, Rect{4, 0, 5, 1})
+	if inner.Clip != (Rect{4, 0, 2, 1}) {
+		t.Errorf("nested clip is %v", inner.Clip)
+	}
+	if inner.Screen != s {
+		t.Error("nested ClipScreen does not draw on the screen directly")
+	}
+	DrawString(3, 0, "世界世", tcell.StyleDefault, inner)
+	DrawString(3, 1, "abc", tcell.StyleDefault, inner)
+	if got := rowText(s, 0); got != "....  ...." {
+		t.Errorf("drew %q", got)
+	}
+	if got := rowText(s, 1); got != ".........." {
+		t.Errorf("drew %q outside the nested clip", got)
+	}
+
+	if apart := NewClipScreen(outer, Rect{7, 0, 2, 2}); apart.Clip.W != 0 {
+		t.Errorf("clip of rects apart is %v", apart.Clip)
+	}
+}
@@ -11,6 +11,7 @@ type Column struct {
 	FocusedIndex    int // Index of child that receives focus
 	OnKeyEvent      func(column *Column, ev *tcell.EventKey) bool
 	focused         bool
+	damage          damage
 }
 
 func (c *Column) FocusNext() {
@@ -97,6 +98,7 @@ func (c *Column) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
 
 func (c *Column) HandleKey(ev *tcell.EventKey) bool {
 	if c.OnKeyEvent != nil && c.OnKeyEvent(c, ev) {
+		c.damage.add() // The callback could change anything
 		return true
 	}
 	for i := range c.Children {
@@ -114,6 +116,27 @@ func (c *Column) SetFocused(b bool) {
 	}
 }
 
+// FocusRect returns the focused part of the focused child, or the rect of the
+// child if it is not a FocusReporter.
+func (c *Column) FocusRect(rect Rect) (Rect, bool) {
+	if !c.focused || c.FocusedIndex >= len(c.Children) {
+		return Rect{}, false
+	}
+	childRect := c.GetChildRects(rect)[c.FocusedIndex]
+	if focus, ok := focusRectOf(c.Children[c.FocusedIndex], childRect); ok {
+		return focus, true
+	}
+	return childRect, true
+}
+
+// TakeDamage reports the damage of every child.
+func (c *Column) TakeDamage() ([]Rect, bool) {
+	for _, child := range c.Children {
+		c.damage.addWidget(child)
+	}
+	return c.damage.take()
+}
+
 func (c *Column) DisplaySize(boundsW, boundsH int) (w, h int) {
 	rects := c.GetChildRects(Rect{0, 0, boundsW, boundsH})
 	if rects == nil {
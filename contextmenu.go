@@ -0,0 +1,88 @@
+package dos
+
+import "github.com/gdamore/tcell/v2"
+
+// A ContextMenu shows a Menu as a popup at a position on the screen, like when
+// right-clicking a widget. The Menu is moved to stay inside the rect it is
+// drawn with. Activating an action, clicking outside of the Menu, or pressing
+// Escape closes it. While open, the ContextMenu handles every key event, so
+// give it events before anything beneath it. Scaffold.PopupMenu opens a
+// ContextMenu above the Scaffold.
+type ContextMenu struct {
+	Menu    *Menu
+	X, Y    int // Position of the top-left corner of the Menu
+	OnClose func()
+	open    bool
+}
+
+// Open shows the Menu at the position, with its first item selected.
+func (c *ContextMenu) Open(x, y int) {
+	c.X, c.Y = x, y
+	c.open = true
+	c.Menu.Collapse()
+	c.Menu.update()
+	c.Menu.Selected = c.Menu.firstSelectable()
+	c.Menu.OnAction = c.Close
+}
+
+// Close hides the Menu and calls OnClose, if it was open.
+func (c *ContextMenu) Close() {
+	if !c.open {
+		return
+	}
+	c.open = false
+	c.Menu.Collapse()
+	if c.OnClose != nil {
+		c.OnClose()
+	}
+}
+
+// IsOpen returns true if the Menu is shown.
+func (c *ContextMenu) IsOpen() bool {
+	return c.open
+}
+
+// menuRect returns the rect of the Menu, moved inside the bounds.
+func (c *ContextMenu) menuRect(bounds Rect) Rect {
+	w, h := c.Menu.DisplaySize(bounds.W, bounds.H)
+	x := Clamp(c.X, bounds.X, bounds.X+bounds.W-w)
+	y := Clamp(c.Y, bounds.Y, bounds.Y+bounds.H-h)
+	return Rect{x, y, w, h}
+}
+
+func (c *ContextMenu) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
+	if !c.open {
+		return false
+	}
+	rect := c.menuRect(currentRect)
+	if c.Menu.HandleMouse(rect, ev) || rect.HasPoint(ev.Position()) {
+		return true
+	}
+	if ev.Buttons()&(tcell.ButtonPrimary|tcell.ButtonSecondary|tcell.ButtonMiddle) != 0 {
+		c.Close() // Clicked outside of the Menu
+		return true
+	}
+	return false
+}
+
+func (c *ContextMenu) HandleKey(ev *tcell.EventKey) bool {
+	if !c.open {
+		return false
+	}
+	if !c.Menu.HandleKey(ev) && ev.Key() == tcell.KeyEscape {
+		c.Close()
+	}
+	return true
+}
+
+func (c *ContextMenu) SetFocused(bool) {}
+
+func (c *ContextMenu) DisplaySize(boundsW, boundsH int) (w, h int) {
+	return boundsW, boundsH
+}
+
+func (c *ContextMenu) Draw(rect Rect, s tcell.Screen) {
+	if c.open {
+		c.Menu.Draw(c.menuRect(rect), s)
+	}
+}
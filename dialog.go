@@ -0,0 +1,424 @@
+package dos
+
+import (
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// DialogResult is the button chosen to close a Dialog.
+type DialogResult uint8
+
+const (
+	DialogCancel DialogResult = iota // Also the result of pressing Escape
+	DialogOK
+	DialogYes
+	DialogNo
+)
+
+// Icons drawn before the message of a Dialog.
+const (
+	IconNone     = ""
+	IconInfo     = "(i)"
+	IconWarning  = "(!)"
+	IconError    = "(x)"
+	IconQuestion = "(?)"
+)
+
+// DialogStyle is the appearance of a Dialog.
+type DialogStyle struct {
+	Window        tcell.Style
+	TitleBar      tcell.Style
+	CloseButton   tcell.Style
+	Button        tcell.Style
+	ButtonFocused tcell.Style
+	Input         tcell.Style
+	InputFocused  tcell.Style
+	Shadow        tcell.Style
+}
+
+// DefaultDialogStyle is the style of the dialogs made by a Scaffold with no
+// DialogStyle.
+var DefaultDialogStyle = DialogStyle{
+	Window:        tcell.Style{}.Background(tcell.ColorLightGray).Foreground(tcell.ColorBlack),
+	TitleBar:      tcell.Style{}.Background(tcell.ColorWhite).Foreground(tcell.ColorBlack),
+	CloseButton:   tcell.Style{}.Background(tcell.ColorRed).Foreground(tcell.ColorBlack),
+	Button:        tcell.Style{}.Background(tcell.ColorWhite).Foreground(tcell.ColorBlack),
+	ButtonFocused: tcell.Style{}.Background(tcell.ColorTeal).Foreground(tcell.ColorWhite),
+	Input:         tcell.Style{}.Background(tcell.ColorWhite).Foreground(tcell.ColorBlack),
+	InputFocused:  tcell.Style{}.Background(tcell.ColorWhite).Foreground(tcell.ColorBlack),
+	Shadow:        tcell.Style{}.Background(tcell.ColorBlack).Foreground(tcell.ColorGray),
+}
+
+// A DialogButton is a button along the bottom of a Dialog. Pressing it closes
+// the Dialog with its Result. The button is also pressed by Alt and its Key,
+// or by only its Key if the Dialog has no Input.
+type DialogButton struct {
+	Text   string
+	Key    rune
+	Result DialogResult
+}
+
+// A Dialog is a Window showing a message, an optional TextInput, and a row of
+// buttons, sized to fit them, centered over everything else, and drawn with a
+// Shadow. Show it with Scaffold.PushDialog, or use one of the ready-made
+// dialogs: MessageBox, Confirm and Prompt.
+type Dialog struct {
+	Title   string
+	Icon    string // Drawn before the Message, like IconInfo
+	Message string
+	Input   *TextInput // Shown below the Message, if not nil
+	Buttons []DialogButton
+	Style   DialogStyle
+	OnClose func(result DialogResult)
+
+	scaffold *Scaffold
+	shadow   *Shadow
+	window   *Window
+	buttons  []*Button
+	focusIdx int    // Index of the focused button, or -1 for the Input
+	moved    bool   // True if the user moved the Dialog from the center
+	pos      [2]int // Position the user moved the Dialog to
+}
+
+// maxDialogTextWidth is the widest a line of the message of a Dialog can be.
+const maxDialogTextWidth = 60
+
+// promptInputWidth is the width of the TextInput of a Prompt.
+const promptInputWidth = 40
+
+// PushDialog shows the Dialog as a modal. The Dialog's OnClose is called with
+// the result when it closes.
+func (s *Scaffold) PushDialog(d *Dialog) {
+	d.scaffold = s
+	d.init()
+	s.PushModal(d, func(result interface{}) {
+		r, _ := result.(DialogResult) // A nil result is DialogCancel
+		if d.OnClose != nil {
+			d.OnClose(r)
+		}
+	})
+}
+
+func (s *Scaffold) dialogStyle() DialogStyle {
+	if s.DialogStyle != nil {
+		return *s.DialogStyle
+	}
+	return DefaultDialogStyle
+}
+
+// MessageBox shows a Dialog with the message and an OK button. The icon, like
+// IconInfo, is drawn before the message. OnClose is called when it is closed,
+// and may be nil.
+func (s *Scaffold) MessageBox(title, icon, message string, onClose func()) {
+	s.PushDialog(&Dialog{
+		Title:   title,
+		Icon:    icon,
+		Message: message,
+		Buttons: []DialogButton{{"OK", 'o', DialogOK}},
+		Style:   s.dialogStyle(),
+		OnClose: func(DialogResult) {
+			if onClose != nil {
+				onClose()
+			}
+		},
+	})
+}
+
+// Confirm shows a Dialog asking the question, with Yes, No and Cancel buttons.
+// OnClose is called with DialogYes, DialogNo or DialogCancel.
+func (s *Scaffold) Confirm(title, question string, onClose func(result DialogResult)) {
+	s.PushDialog(&Dialog{
+		Title:   title,
+		Icon:    IconQuestion,
+		Message: question,
+		Buttons: []DialogButton{
+			{"Yes", 'y', DialogYes},
+			{"No", 'n', DialogNo},
+			{"Cancel", 'c', DialogCancel},
+		},
+		Style:   s.dialogStyle(),
+		OnClose: onClose,
+	})
+}
+
+// Prompt shows a Dialog with the message and a TextInput holding the text, with
+// OK and Cancel buttons. OnClose is called with the text entered, and ok set to
+// true if OK was pressed, or Enter was pressed in the TextInput.
+func (s *Scaffold) Prompt(title, message, text string, onClose func(text string, ok bool)) {
+	style := s.dialogStyle()
+	input := &TextInput{
+		Text:         text,
+		Width:        promptInputWidth,
+		NormalStyle:  style.Input,
+		FocusedStyle: style.InputFocused,
+	}
+	input.SetCursorPos(len(text))
+	s.PushDialog(&Dialog{
+		Title:   title,
+		Message: message,
+		Input:   input,
+		Buttons: []DialogButton{
+			{"OK", 'o', DialogOK},
+			{"Cancel", 'c', DialogCancel},
+		},
+		Style: style,
+		OnClose: func(result DialogResult) {
+			if onClose != nil {
+				onClose(input.Text, result == DialogOK)
+			}
+		},
+	})
+}
+
+// Close closes the Dialog with the result, as though a button was pressed.
+func (d *Dialog) Close(result DialogResult) {
+	if d.scaffold != nil {
+		d.scaffold.PopModal(result)
+	}
+}
+
+// init makes the widgets of the Dialog.
+func (d *Dialog) init() {
+	d.buttons = make([]*Button, len(d.Buttons))
+	for i := range d.Buttons {
+		result := d.Buttons[i].Result
+		d.buttons[i] = &Button{
+			Text:         d.Buttons[i].Text,
+			NormalStyle:  d.Style.Button,
+			FocusedStyle: d.Style.ButtonFocused,
+			OnPressed:    func() { d.Close(result) },
+		}
+	}
+	d.window = &Window{
+		Title:            d.Title,
+		Child:            &dialogBody{d},
+		OnClosed:         func() { d.Close(DialogCancel) },
+		OnMove:           func(newX, newY int) { d.moved = true; d.pos = [2]int{newX, newY} },
+		CloseButtonStyle: d.Style.CloseButton,
+		TitleBarStyle:    d.Style.TitleBar,
+		WindowStyle:      d.Style.Window,
+	}
+	d.shadow = &Shadow{Child: d.window, Style: d.Style.Shadow}
+	if d.Input != nil {
+		d.focusIdx = -1
+	}
+}
+
+// messageLines returns the lines of the message wrapped to fit the bounds.
+func (d *Dialog) messageLines(boundsW int) (lines []string, width int) {
+	iconWidth := d.iconWidth()
+	rect := Rect{0, 0, Max(Min(maxDialogTextWidth, boundsW-4-iconWidth), 1), 1 << 16}
+	lines, width, _ = ConfineString(d.Message, rect, "\n")
+	return lines, width + iconWidth
+}
+
+func (d *Dialog) iconWidth() int {
+	if d.Icon == "" {
+		return 0
+	}
+	return runewidth.StringWidth(d.Icon) + 1
+}
+
+// buttonsWidth returns the width of the row of buttons.
+func (d *Dialog) buttonsWidth() int {
+	width := 0
+	for i, b := range d.buttons {
+		w, _ := b.DisplaySize(0, 0)
+		width += w
+		if i > 0 {
+			width += 2
+		}
+	}
+	return width
+}
+
+// rect returns the rect of the Window in the bounds.
+func (d *Dialog) rect(bounds Rect) Rect {
+	lines, textW := d.messageLines(bounds.W)
+	contentW := Max(textW, d.buttonsWidth())
+	contentH := len(lines) + 2
+	if d.Input != nil {
+		inputW, _ := d.Input.DisplaySize(bounds.W-4, 1)
+		contentW = Max(contentW, inputW)
+		contentH += 2
+	}
+	// Margins of two columns and one row, and the title bar
+	w := Min(contentW+4, bounds.W-2)
+	h := Min(contentH+3, bounds.H-1)
+	if d.moved {
+		return Rect{d.pos[0], d.pos[1], w, h}
+	}
+	return Rect{bounds.X + (bounds.W-w)/2, bounds.Y + (bounds.H-h)/2, w, h}
+}
+
+func (d *Dialog) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
+	if d.shadow == nil {
+		return false
+	}
+	return d.shadow.HandleMouse(d.rect(currentRect), ev)
+}
+
+func (d *Dialog) HandleKey(ev *tcell.EventKey) bool {
+	if d.window == nil {
+		return false
+	}
+	return d.window.HandleKey(ev)
+}
+
+func (d *Dialog) SetFocused(b bool) {
+	if d.window == nil {
+		return
+	}
+	if d.focusIdx < 0 {
+		d.Input.SetFocused(b)
+	} else if d.focusIdx < len(d.buttons) {
+		d.buttons[d.focusIdx].SetFocused(b)
+	}
+}
+
+func (d *Dialog) DisplaySize(boundsW, boundsH int) (w, h int) {
+	return boundsW, boundsH
+}
+
+func (d *Dialog) Draw(rect Rect, s tcell.Screen) {
+	if d.shadow != nil {
+		d.shadow.Draw(d.rect(rect), s)
+	}
+}
+
+// focus moves the focus to the index of a button, or -1 for the Input.
+func (d *Dialog) focus(idx int) {
+	d.SetFocused(false)
+	d.focusIdx = idx
+	d.SetFocused(true)
+}
+
+// focusNext moves the focus forward, or backward if n is negative.
+func (d *Dialog) focusNext(n int) {
+	first := 0
+	if d.Input != nil {
+		first = -1
+	}
+	count := len(d.buttons) - first
+	if count < 2 {
+		return
+	}
+	d.focus(((d.focusIdx-first+n)%count+count)%count + first)
+}
+
+// A dialogBody is the Child of the Window of a Dialog.
+type dialogBody struct {
+	d *Dialog
+}
+
+// childRects returns the rect of the Input and of each button.
+func (b *dialogBody) childRects(rect Rect) (input Rect, buttons []Rect) {
+	d := b.d
+	lines, _ := d.messageLines(rect.W)
+	y := rect.Y + 1 + len(lines) + 1
+	if d.Input != nil {
+		w, _ := d.Input.DisplaySize(rect.W-4, 1)
+		input = Rect{rect.X + 2, y, w, 1}
+		y += 2
+	}
+	x := rect.X + (rect.W-d.buttonsWidth())/2
+	buttons = make([]Rect, len(d.buttons))
+	for i, button := range d.buttons {
+		w, _ := button.DisplaySize(0, 0)
+		buttons[i] = Rect{x, y, w, 1}
+		x += w + 2
+	}
+	return input, buttons
+}
+
+func (b *dialogBody) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
+	d := b.d
+	input, buttons := b.childRects(currentRect)
+	if d.Input != nil && input.HasPoint(ev.Position()) && ev.Buttons()&tcell.ButtonPrimary != 0 {
+		d.focus(-1)
+		return d.Input.HandleMouse(input, ev)
+	}
+	for i, button := range d.buttons {
+		if buttons[i].HasPoint(ev.Position()) && ev.Buttons()&tcell.ButtonPrimary != 0 {
+			d.focus(i)
+			return button.HandleMouse(buttons[i], ev)
+		}
+	}
+	return false
+}
+
+func (b *dialogBody) HandleKey(ev *tcell.EventKey) bool {
+	d := b.d
+	switch ev.Key() {
+	case tcell.KeyTab:
+		d.focusNext(1)
+		return true
+	case tcell.KeyBacktab:
+		d.focusNext(-1)
+		return true
+	case tcell.KeyEnter:
+		if d.focusIdx < 0 && len(d.buttons) > 0 {
+			d.buttons[0].Press() // Enter in the Input presses the first button
+			return true
+		}
+	case tcell.KeyRune:
+		alt := ev.Modifiers()&tcell.ModAlt != 0
+		if alt || d.Input == nil || d.focusIdx >= 0 {
+			for i, button := range d.Buttons {
+				if button.Key != 0 && unicode.ToLower(ev.Rune()) == unicode.ToLower(button.Key) {
+					d.buttons[i].Press()
+					return true
+				}
+			}
+		}
+	}
+
+	if d.focusIdx < 0 {
+		return d.Input.HandleKey(ev)
+	}
+	switch ev.Key() {
+	case tcell.KeyLeft:
+		d.focusNext(-1)
+		return true
+	case tcell.KeyRight:
+		d.focusNext(1)
+		return true
+	}
+	if d.focusIdx < len(d.buttons) {
+		return d.buttons[d.focusIdx].HandleKey(ev)
+	}
+	return false
+}
+
+func (b *dialogBody) SetFocused(v bool) {
+	b.d.SetFocused(v)
+}
+
+func (b *dialogBody) DisplaySize(boundsW, boundsH int) (w, h int) {
+	return boundsW, boundsH
+}
+
+func (b *dialogBody) Draw(rect Rect, s tcell.Screen) {
+	d := b.d
+	lines, _ := d.messageLines(rect.W)
+	textX := rect.X + 2
+	if d.Icon != "" {
+		DrawString(textX, rect.Y+1, d.Icon, d.Style.Window, s)
+		textX += d.iconWidth()
+	}
+	for i, line := range lines {
+		if rect.Y+1+i >= rect.Y+rect.H {
+			break
+		}
+		DrawString(textX, rect.Y+1+i, line, d.Style.Window, s)
+	}
+	input, buttons := b.childRects(rect)
+	if d.Input != nil {
+		d.Input.Draw(input, s)
+	}
+	for i, button := range d.buttons {
+		button.Draw(buttons[i], s)
+	}
+}
@@ -0,0 +1,85 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fivemoreminix/dos"
+	"github.com/gdamore/tcell/v2"
+)
+
+func main() {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create tcell screen: %v", err)
+	}
+	if err = screen.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize: %v", err)
+	}
+
+	label := &dos.Label{
+		Text:  "Press Ctrl+O to open a file, or Ctrl+Q to quit",
+		Style: tcell.StyleDefault,
+	}
+	scaffold := &dos.Scaffold{MainWidget: &dos.Center{Child: label}}
+	popup := &dos.Menu{
+		Items: []dos.MenuItem{
+			{Title: "&Clear", Action: func() { label.Text = "" }},
+			{Title: "&About", Action: func() {
+				scaffold.MessageBox("About", dos.IconInfo, "A demo of the dialogs of dos.", nil)
+			}},
+		},
+		Decorated:      true,
+		NormalStyle:    tcell.StyleDefault.Background(tcell.ColorSilver).Foreground(tcell.ColorBlack),
+		SelectionStyle: tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorSilver),
+	}
+
+	var app dos.App
+	open := func() {
+		scaffold.OpenFile("Open", []string{"*.go", "*.md"}, func(path string, ok bool) {
+			if ok {
+				label.Text = "Opened " + path
+			}
+		})
+	}
+	quit := func() {
+		scaffold.Prompt("Quit", "Type your name to say goodbye:", "", func(name string, ok bool) {
+			if !ok {
+				return
+			}
+			scaffold.Confirm("Quit", "Goodbye, "+name+". Quit now?", func(result dos.DialogResult) {
+				switch result {
+				case dos.DialogYes:
+					app.Running = false
+				case dos.DialogNo:
+					scaffold.MessageBox("Quit", dos.IconInfo, "Staying, then.", nil)
+				}
+			})
+		})
+	}
+	scaffold.StatusBar = &dos.StatusBar{
+		Items: []dos.StatusItem{
+			{Key: "Ctrl+O", Text: "Open", Action: open},
+			{Key: "Ctrl+Q", Text: "Quit", Action: quit},
+			{Text: "Right-click for a menu", Right: true},
+		},
+		NormalStyle: tcell.StyleDefault.Background(tcell.ColorSilver).Foreground(tcell.ColorBlack),
+		KeyStyle:    tcell.StyleDefault.Background(tcell.ColorSilver).Foreground(tcell.ColorMaroon),
+	}
+
+	app = dos.App{
+		MainWidget: scaffold,
+		OnMouseEvent: func(ev *tcell.EventMouse) bool {
+			if scaffold.HasModal() || ev.Buttons()&tcell.ButtonSecondary == 0 {
+				return false
+			}
+			x, y := ev.Position()
+			scaffold.PopupMenu(popup, x, y)
+			return true
+		},
+	}
+	app.Run(screen)
+}
@@ -0,0 +1,60 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/fivemoreminix/dos"
+	"github.com/gdamore/tcell/v2"
+)
+
+// numbers is a ListSource of many rows, which are never stored.
+type numbers int
+
+func (n numbers) Len() int {
+	return int(n)
+}
+
+func (n numbers) Label(i int) string {
+	return strconv.Itoa(i + 1)
+}
+
+func main() {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create tcell screen: %v", err)
+	}
+	if err = screen.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize: %v", err)
+	}
+
+	list := &dos.ListView{
+		Source:        numbers(100000),
+		MultiSelect:   true,
+		NormalStyle:   tcell.StyleDefault,
+		CursorStyle:   tcell.StyleDefault.Reverse(true),
+		SelectedStyle: tcell.StyleDefault.Background(tcell.ColorNavy).Foreground(tcell.ColorWhite),
+	}
+	var app dos.App
+	scaffold := &dos.Scaffold{
+		MainWidget: list,
+		StatusBar: &dos.StatusBar{
+			Items: []dos.StatusItem{
+				{Key: "Space", Text: "Select"},
+				{Key: "Ctrl+Q", Text: "Quit", Action: func() { app.Running = false }},
+				{Text: "Type a number to find its row", Right: true},
+			},
+			NormalStyle: tcell.StyleDefault.Background(tcell.ColorSilver).Foreground(tcell.ColorBlack),
+		},
+	}
+	list.OnActivate = func(i int) {
+		message := fmt.Sprintf("Row %s was activated, and %d rows are selected.", numbers(0).Label(i), len(list.Selection()))
+		scaffold.MessageBox("List", dos.IconInfo, message, nil)
+	}
+	app = dos.App{MainWidget: scaffold}
+	app.Run(screen)
+}
@@ -0,0 +1,70 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/fivemoreminix/dos"
+	"github.com/fivemoreminix/dos/vterm"
+	"github.com/gdamore/tcell/v2"
+)
+
+var (
+	defaultStyle = tcell.Style{}.Background(tcell.ColorBlue).Foreground(tcell.ColorGrey)
+	windowStyle  = tcell.Style{}.Background(tcell.ColorLightBlue).Foreground(tcell.ColorBlack)
+)
+
+func main() {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create tcell screen: %v", err)
+	}
+	if err = screen.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize: %v", err)
+	}
+
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "sh"
+	}
+
+	var app dos.App
+	term := vterm.NewTerminal(80, 24)
+	term.OnUpdate = app.RequestUpdate
+	term.OnExit = func(error) {
+		_ = app.Post(func() { app.Running = false })
+	}
+	if err = term.Start(exec.Command(shell)); err != nil {
+		screen.Fini()
+		fmt.Fprintf(os.Stderr, "failed to start shell: %v", err)
+		os.Exit(1)
+	}
+	defer term.Close()
+
+	align := &dos.Align{
+		Positioning: dos.Absolute,
+		Rect:        dos.Rect{X: 4, Y: 2, W: 82, H: 26},
+	}
+	align.Child = &dos.Shadow{
+		Child: &dos.Window{
+			Title:            "Shell",
+			Child:            &dos.Terminal{Term: term},
+			OnClosed:         func() { app.Running = false },
+			OnMove:           func(posX, posY int) { align.Rect.X = posX; align.Rect.Y = posY },
+			CloseButtonStyle: tcell.Style{}.Background(tcell.ColorRed).Foreground(tcell.ColorBlack),
+			TitleBarStyle:    windowStyle.Background(tcell.ColorWhite),
+			WindowStyle:      windowStyle,
+		},
+		Style: tcell.Style{}.Background(tcell.ColorDarkCyan).Foreground(tcell.ColorBlack),
+	}
+
+	app.ClearStyle = defaultStyle
+	app.MainWidget = &dos.Scaffold{
+		Floating: []dos.Widget{align},
+	}
+	app.Run(screen)
+}
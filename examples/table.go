@@ -0,0 +1,94 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/fivemoreminix/dos"
+	"github.com/gdamore/tcell/v2"
+)
+
+type planet struct {
+	name, kind string
+	moons      int
+	radius     float64 // In kilometers
+}
+
+// planets is a TableModel whose names can be edited. The Table sorts it.
+type planets []planet
+
+func (p planets) Rows() int {
+	return len(p)
+}
+
+func (p planets) Cell(row, col int) string {
+	switch col {
+	case 0:
+		return p[row].name
+	case 1:
+		return p[row].kind
+	case 2:
+		return strconv.Itoa(p[row].moons)
+	default:
+		return strconv.FormatFloat(p[row].radius, 'f', 1, 64)
+	}
+}
+
+func (p planets) SetCell(row, col int, text string) {
+	if col == 0 {
+		p[row].name = text
+	}
+}
+
+func main() {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create tcell screen: %v", err)
+	}
+	if err = screen.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize: %v", err)
+	}
+
+	table := &dos.Table{
+		Model: planets{
+			{"Mercury", "Terrestrial", 0, 2439.7},
+			{"Venus", "Terrestrial", 0, 6051.8},
+			{"Earth", "Terrestrial", 1, 6371.0},
+			{"Mars", "Terrestrial", 2, 3389.5},
+			{"Jupiter", "Gas giant", 95, 69911},
+			{"Saturn", "Gas giant", 146, 58232},
+			{"Uranus", "Ice giant", 28, 25362},
+			{"Neptune", "Ice giant", 16, 24622},
+		},
+		Columns: []dos.TableColumn{
+			{Title: "Name", WidthType: dos.ColumnAuto, Editable: true},
+			{Title: "Kind", Width: 100, WidthType: dos.ColumnPercent},
+			{Title: "Moons", Width: 7, Align: dos.AlignRight},
+			{Title: "Radius (km)", WidthType: dos.ColumnAuto, Align: dos.AlignRight},
+		},
+		NormalStyle: tcell.StyleDefault,
+		HeaderStyle: tcell.StyleDefault.Bold(true).Underline(true),
+		CursorStyle: tcell.StyleDefault.Background(tcell.ColorNavy).Foreground(tcell.ColorWhite),
+	}
+	var app dos.App
+	scaffold := &dos.Scaffold{
+		MainWidget: table,
+		StatusBar: &dos.StatusBar{
+			Items: []dos.StatusItem{
+				{Key: "F2", Text: "Rename"},
+				{Key: "Ctrl+Q", Text: "Quit", Action: func() { app.Running = false }},
+				{Text: "Click a title to sort", Right: true},
+			},
+			NormalStyle: tcell.StyleDefault.Background(tcell.ColorSilver).Foreground(tcell.ColorBlack),
+		},
+	}
+	table.OnActivate = func(row int) {
+		scaffold.MessageBox("Table", dos.IconInfo, table.Model.Cell(row, 0)+" was activated.", nil)
+	}
+	app = dos.App{MainWidget: scaffold}
+	app.Run(screen)
+}
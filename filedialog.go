@@ -0,0 +1,497 @@
+package dos
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// FileDialogMode is whether a FileDialog opens an existing file or names a file
+// to save.
+type FileDialogMode uint8
+
+const (
+	FileOpen FileDialogMode = iota // The file must exist
+	FileSave                       // Replacing an existing file must be confirmed
+)
+
+// A FileRoot is a file system a FileDialog can browse, like a drive.
+type FileRoot struct {
+	Name string // Shown in the directory list, like "C:" or "/"
+	Path string // Path of the root on the operating system, or empty
+	FS   fs.FS
+}
+
+// SystemRoots returns the roots of the operating system's file systems: every
+// drive on Windows, and "/" everywhere else.
+func SystemRoots() []FileRoot {
+	if runtime.GOOS != "windows" {
+		return []FileRoot{{Name: "/", Path: "/", FS: os.DirFS("/")}}
+	}
+	var roots []FileRoot
+	for drive := 'A'; drive <= 'Z'; drive++ {
+		name := string(drive) + ":"
+		if _, err := os.Stat(name + `\`); err == nil {
+			roots = append(roots, FileRoot{Name: name, Path: name + `\`, FS: os.DirFS(name + `\`)})
+		}
+	}
+	return roots
+}
+
+// systemDir returns the index of the root containing the directory, and the
+// slash-separated path of the directory in that root.
+func systemDir(roots []FileRoot, dir string) (root int, rel string) {
+	for i := range roots {
+		if r, err := filepath.Rel(roots[i].Path, dir); err == nil && !strings.HasPrefix(r, "..") {
+			return i, filepath.ToSlash(r)
+		}
+	}
+	return 0, "."
+}
+
+// A FileDialog lets the user choose a file like the dialogs of DOS: a file name
+// input, a list of the files in the directory matching the patterns, and a list
+// of the subdirectories and roots to change to. Typing a pattern like *.txt in
+// the file name input changes the patterns. Show it with Scaffold.PushFileDialog,
+// or use OpenFile or SaveFile for the operating system's files.
+type FileDialog struct {
+	Title    string
+	Mode     FileDialogMode
+	Roots    []FileRoot
+	Root     int      // Index of the root being browsed
+	Dir      string   // Slash-separated path of the directory in the root
+	Patterns []string // Patterns of files to list, like "*.go", or all files if empty
+	FileName string   // Initial contents of the file name input
+	Style    DialogStyle
+	// OnClose is called with the path of the chosen file, and ok set to false
+	// if the dialog was canceled. See Path.
+	OnClose func(path string, ok bool)
+
+	scaffold *Scaffold
+	shadow   *Shadow
+	window   *Window
+	input    *TextInput
+	files    *ListView
+	dirs     *ListView
+	dirItems []fileDirItem // Directory or root of each item of dirs
+	buttons  []*Button
+	focusIdx int // Index into focusables
+	moved    bool
+	pos      [2]int
+}
+
+// A fileDirItem is a directory in a root, listed in a FileDialog.
+type fileDirItem struct {
+	root int
+	dir  string
+}
+
+// fileDialogSize is the largest a FileDialog will be drawn.
+var fileDialogSize = [2]int{64, 20}
+
+// PushFileDialog shows the FileDialog as a modal. The FileDialog's OnClose is
+// called with the result when it closes.
+func (s *Scaffold) PushFileDialog(d *FileDialog) {
+	d.scaffold = s
+	d.init()
+	s.PushModal(d, func(result interface{}) {
+		name, ok := result.(string) // A nil result is canceled
+		if d.OnClose != nil {
+			if ok {
+				d.OnClose(d.Path(name), true)
+			} else {
+				d.OnClose("", false)
+			}
+		}
+	})
+}
+
+// OpenFile shows a FileDialog of the operating system's files, beginning in the
+// working directory, to choose an existing file matching the patterns.
+func (s *Scaffold) OpenFile(title string, patterns []string, onClose func(path string, ok bool)) {
+	s.PushFileDialog(s.systemFileDialog(title, FileOpen, "", patterns, onClose))
+}
+
+// SaveFile shows a FileDialog of the operating system's files, beginning in the
+// working directory, to name a file to save, with name as the initial name.
+func (s *Scaffold) SaveFile(title, name string, patterns []string, onClose func(path string, ok bool)) {
+	s.PushFileDialog(s.systemFileDialog(title, FileSave, name, patterns, onClose))
+}
+
+func (s *Scaffold) systemFileDialog(title string, mode FileDialogMode, name string, patterns []string, onClose func(string, bool)) *FileDialog {
+	d := &FileDialog{
+		Title:    title,
+		Mode:     mode,
+		Roots:    SystemRoots(),
+		Dir:      ".",
+		Patterns: patterns,
+		FileName: name,
+		Style:    s.dialogStyle(),
+		OnClose:  onClose,
+	}
+	if wd, err := os.Getwd(); err == nil {
+		d.Root, d.Dir = systemDir(d.Roots, wd)
+	}
+	return d
+}
+
+// Path returns the path of the slash-separated name in the current root, on
+// the operating system if the root has a Path.
+func (d *FileDialog) Path(name string) string {
+	if d.Root < len(d.Roots) && d.Roots[d.Root].Path != "" {
+		return filepath.Join(d.Roots[d.Root].Path, filepath.FromSlash(name))
+	}
+	return name
+}
+
+// FS returns the file system of the current root.
+func (d *FileDialog) FS() fs.FS {
+	if d.Root < len(d.Roots) {
+		return d.Roots[d.Root].FS
+	}
+	return nil
+}
+
+// Close closes the FileDialog, choosing the file with the slash-separated name
+// in the current root.
+func (d *FileDialog) Close(name string) {
+	if d.scaffold != nil {
+		d.scaffold.PopModal(name)
+	}
+}
+
+// Cancel closes the FileDialog without choosing a file.
+func (d *FileDialog) Cancel() {
+	if d.scaffold != nil {
+		d.scaffold.CancelModal()
+	}
+}
+
+func (d *FileDialog) init() {
+	d.input = &TextInput{
+		Text:         d.FileName,
+		NormalStyle:  d.Style.Input,
+		FocusedStyle: d.Style.InputFocused,
+	}
+	d.input.SetCursorPos(len([]rune(d.FileName)))
+	d.files = &ListView{
+		NormalStyle: d.Style.Input,
+		CursorStyle: d.Style.ButtonFocused,
+		OnSelect:    func(i int) { d.setFileName(d.files.Source.Label(i)) },
+		OnActivate:  func(i int) { d.accept(d.files.Source.Label(i)) },
+	}
+	d.dirs = &ListView{
+		NormalStyle: d.Style.Input,
+		CursorStyle: d.Style.ButtonFocused,
+		OnActivate:  func(i int) { d.openDir(i) },
+	}
+	okText := "Open"
+	if d.Mode == FileSave {
+		okText = "Save"
+	}
+	d.buttons = []*Button{
+		{Text: okText, OnPressed: func() { d.accept(d.input.Text) }},
+		{Text: "Cancel", OnPressed: d.Cancel},
+	}
+	for _, b := range d.buttons {
+		b.NormalStyle, b.FocusedStyle = d.Style.Button, d.Style.ButtonFocused
+	}
+	d.window = &Window{
+		Title:            d.Title,
+		Child:            &fileDialogBody{d},
+		OnClosed:         d.Cancel,
+		OnMove:           func(newX, newY int) { d.moved = true; d.pos = [2]int{newX, newY} },
+		CloseButtonStyle: d.Style.CloseButton,
+		TitleBarStyle:    d.Style.TitleBar,
+		WindowStyle:      d.Style.Window,
+	}
+	d.shadow = &Shadow{Child: d.window, Style: d.Style.Shadow}
+	if d.Dir == "" {
+		d.Dir = "."
+	}
+	if err := d.readDir(d.Root, d.Dir); err != nil {
+		_ = d.readDir(d.Root, ".")
+	}
+}
+
+// readDir lists the directory of the root, and makes it the current directory
+// if it can be read.
+func (d *FileDialog) readDir(root int, dir string) error {
+	if root < 0 || root >= len(d.Roots) || d.Roots[root].FS == nil {
+		return fs.ErrNotExist
+	}
+	fsys := d.Roots[root].FS
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	d.Root, d.Dir = root, dir
+
+	var files, dirs []string
+	var dirItems []fileDirItem
+	if dir != "." {
+		dirs = append(dirs, "..")
+		dirItems = append(dirItems, fileDirItem{root, path.Dir(dir)})
+	}
+	for _, e := range entries {
+		isDir := e.IsDir()
+		if e.Type()&fs.ModeSymlink != 0 {
+			if info, err := fs.Stat(fsys, path.Join(dir, e.Name())); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+		if isDir {
+			dirs = append(dirs, e.Name()+"/")
+			dirItems = append(dirItems, fileDirItem{root, path.Join(dir, e.Name())})
+		} else if d.matches(e.Name()) {
+			files = append(files, e.Name())
+		}
+	}
+	if len(d.Roots) > 1 {
+		for i := range d.Roots {
+			dirs = append(dirs, "["+d.Roots[i].Name+"]")
+			dirItems = append(dirItems, fileDirItem{i, "."})
+		}
+	}
+	d.files.SetSource(StringList(files))
+	d.dirs.SetSource(StringList(dirs))
+	d.dirItems = dirItems
+	return nil
+}
+
+// matches returns true if the file name matches any of the Patterns, ignoring
+// case like DOS.
+func (d *FileDialog) matches(name string) bool {
+	if len(d.Patterns) == 0 {
+		return true
+	}
+	for _, pattern := range d.Patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *FileDialog) setFileName(name string) {
+	d.input.Text = name
+	d.input.SetCursorPos(len([]rune(name)))
+}
+
+// openDir changes to the directory or root of the item of the directory list.
+func (d *FileDialog) openDir(i int) {
+	if i < 0 || i >= len(d.dirItems) {
+		return
+	}
+	if err := d.readDir(d.dirItems[i].root, d.dirItems[i].dir); err != nil {
+		d.scaffold.MessageBox(d.Title, IconError, err.Error(), nil)
+	}
+}
+
+// resolve returns the slash-separated path in the root of a name typed by the
+// user, relative to the current directory unless it begins with a slash.
+func (d *FileDialog) resolve(name string) string {
+	name = filepath.ToSlash(name)
+	if !strings.HasPrefix(name, "/") {
+		name = path.Join(d.Dir, name)
+	}
+	name = path.Clean("/" + name)[1:] // Cannot go above the root
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// accept handles the name typed or chosen by the user: a pattern changes the
+// Patterns, a directory is changed to, and a file closes the FileDialog.
+func (d *FileDialog) accept(name string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return
+	}
+	if strings.ContainsAny(name, "*?[") {
+		d.Patterns = strings.FieldsFunc(name, func(r rune) bool { return r == ';' || r == ' ' })
+		d.setFileName("")
+		_ = d.readDir(d.Root, d.Dir)
+		return
+	}
+
+	fsys := d.FS()
+	if fsys == nil { // There are no Roots
+		d.scaffold.MessageBox(d.Title, IconError, "Cannot use "+name+": there is no drive to browse.", nil)
+		return
+	}
+	p := d.resolve(name)
+	info, err := fs.Stat(fsys, p)
+	switch {
+	case err == nil && info.IsDir():
+		if err := d.readDir(d.Root, p); err != nil {
+			d.scaffold.MessageBox(d.Title, IconError, err.Error(), nil)
+		} else {
+			d.setFileName("")
+		}
+	case d.Mode == FileOpen && err != nil:
+		d.scaffold.MessageBox(d.Title, IconError, "Cannot open "+name+": file not found.", nil)
+	case d.Mode == FileSave && err == nil:
+		d.scaffold.Confirm(d.Title, name+" already exists. Replace it?", func(result DialogResult) {
+			if result == DialogYes {
+				d.Close(p)
+			}
+		})
+	default:
+		d.Close(p)
+	}
+}
+
+// focusables returns the widgets of the FileDialog in the order Tab moves the
+// focus through them.
+func (d *FileDialog) focusables() []Widget {
+	return []Widget{d.input, d.files, d.dirs, d.buttons[0], d.buttons[1]}
+}
+
+func (d *FileDialog) focus(idx int) {
+	d.SetFocused(false)
+	d.focusIdx = idx
+	d.SetFocused(true)
+}
+
+// rect returns the rect of the Window in the bounds.
+func (d *FileDialog) rect(bounds Rect) Rect {
+	w, h := Min(fileDialogSize[0], bounds.W-2), Min(fileDialogSize[1], bounds.H-1)
+	if d.moved {
+		return Rect{d.pos[0], d.pos[1], w, h}
+	}
+	return Rect{bounds.X + (bounds.W-w)/2, bounds.Y + (bounds.H-h)/2, w, h}
+}
+
+func (d *FileDialog) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
+	if d.shadow == nil {
+		return false
+	}
+	return d.shadow.HandleMouse(d.rect(currentRect), ev)
+}
+
+func (d *FileDialog) HandleKey(ev *tcell.EventKey) bool {
+	if d.window == nil {
+		return false
+	}
+	return d.window.HandleKey(ev)
+}
+
+func (d *FileDialog) SetFocused(b bool) {
+	if d.window != nil {
+		d.focusables()[d.focusIdx].SetFocused(b)
+	}
+}
+
+func (d *FileDialog) DisplaySize(boundsW, boundsH int) (w, h int) {
+	return boundsW, boundsH
+}
+
+func (d *FileDialog) Draw(rect Rect, s tcell.Screen) {
+	if d.shadow != nil {
+		d.shadow.Draw(d.rect(rect), s)
+	}
+}
+
+// A fileDialogBody is the Child of the Window of a FileDialog.
+type fileDialogBody struct {
+	d *FileDialog
+}
+
+// childRects returns the rect of each of the focusables of the FileDialog.
+func (b *fileDialogBody) childRects(rect Rect) []Rect {
+	listW := (rect.W - 6) / 2
+	listH := Max(rect.H-8, 1)
+	okW, _ := b.d.buttons[0].DisplaySize(0, 0)
+	cancelW, _ := b.d.buttons[1].DisplaySize(0, 0)
+	buttonsX := rect.X + (rect.W-okW-cancelW-2)/2
+	return []Rect{
+		{rect.X + 13, rect.Y + 1, Max(rect.W-15, 1), 1},
+		{rect.X + 2, rect.Y + 5, listW, listH},
+		{rect.X + 4 + listW, rect.Y + 5, listW, listH},
+		{buttonsX, rect.Y + rect.H - 2, okW, 1},
+		{buttonsX + okW + 2, rect.Y + rect.H - 2, cancelW, 1},
+	}
+}
+
+func (b *fileDialogBody) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
+	rects := b.childRects(currentRect)
+	for i, w := range b.d.focusables() {
+		if !rects[i].HasPoint(ev.Position()) {
+			continue
+		}
+		if ev.Buttons()&tcell.ButtonPrimary != 0 {
+			b.d.focus(i)
+		}
+		return w.HandleMouse(rects[i], ev)
+	}
+	return false
+}
+
+func (b *fileDialogBody) HandleKey(ev *tcell.EventKey) bool {
+	d := b.d
+	count := len(d.focusables())
+	switch ev.Key() {
+	case tcell.KeyTab:
+		d.focus((d.focusIdx + 1) % count)
+		return true
+	case tcell.KeyBacktab:
+		d.focus((d.focusIdx + count - 1) % count)
+		return true
+	case tcell.KeyEnter:
+		if d.focusIdx == 0 {
+			d.accept(d.input.Text)
+			return true
+		}
+	}
+	return d.focusables()[d.focusIdx].HandleKey(ev)
+}
+
+func (b *fileDialogBody) SetFocused(v bool) {
+	b.d.SetFocused(v)
+}
+
+func (b *fileDialogBody) DisplaySize(boundsW, boundsH int) (w, h int) {
+	return boundsW, boundsH
+}
+
+func (b *fileDialogBody) Draw(rect Rect, s tcell.Screen) {
+	d := b.d
+	style := d.Style.Window
+	rects := b.childRects(rect)
+
+	DrawString(rect.X+2, rect.Y+1, "File name:", style, s)
+
+	// Show the end of a long directory, where the user is
+	dir := d.Dir
+	if d.Root < len(d.Roots) && d.Roots[d.Root].Path != "" {
+		dir = d.Path(d.Dir)
+	} else if d.Root < len(d.Roots) {
+		dir = strings.TrimSuffix(d.Roots[d.Root].Name, "/") + "/" + strings.TrimPrefix(d.Dir, ".")
+	}
+	label := "Directory: "
+	maxW := rect.W - 4 - runewidth.StringWidth(label)
+	for runewidth.StringWidth(dir) > maxW && dir != "" {
+		_, size := utf8.DecodeRuneInString(dir)
+		dir = dir[size:]
+		if runewidth.StringWidth(dir)+1 <= maxW {
+			dir = "…" + dir
+			break
+		}
+	}
+	DrawString(rect.X+2, rect.Y+3, label+dir, style, s)
+
+	DrawString(rects[1].X, rects[1].Y-1, "Files:", style, s)
+	DrawString(rects[2].X, rects[2].Y-1, "Directories:", style, s)
+	for i, w := range d.focusables() {
+		w.Draw(rects[i], s)
+	}
+}
@@ -0,0 +1,158 @@
+package dos
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var testFS = fstest.MapFS{
+	"a.go":          {},
+	"b.TXT":         {},
+	"c.md":          {},
+	"sub/x.go":      {},
+	"sub/deep/y.go": {},
+}
+
+// labels returns the labels of every item of the ListView.
+func labels(l *ListView) []string {
+	var items []string
+	for i := 0; i < l.Len(); i++ {
+		items = append(items, l.Source.Label(i))
+	}
+	return items
+}
+
+// enterName types the name in the file name input of the FileDialog and
+// presses Enter.
+func enterName(d *FileDialog, name string) {
+	d.focus(0)
+	d.setFileName(name)
+	d.HandleKey(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+}
+
+// pushTestDialog shows the FileDialog in a new Scaffold, and returns the
+// Scaffold and a pointer to the path the FileDialog closes with.
+func pushTestDialog(d *FileDialog) (*Scaffold, *string) {
+	s := &Scaffold{}
+	closed := new(string)
+	d.OnClose = func(path string, ok bool) {
+		if ok {
+			*closed = path
+		} else {
+			*closed = "canceled"
+		}
+	}
+	s.PushFileDialog(d)
+	return s, closed
+}
+
+func TestFileDialogPatterns(t *testing.T) {
+	d := &FileDialog{Roots: []FileRoot{{Name: "/", FS: testFS}}, Patterns: []string{"*.go"}}
+	pushTestDialog(d)
+	if files := labels(d.files); !reflect.DeepEqual(files, []string{"a.go"}) {
+		t.Errorf("files are %q", files)
+	}
+	if dirs := labels(d.dirs); !reflect.DeepEqual(dirs, []string{"sub/"}) {
+		t.Errorf("directories are %q", dirs)
+	}
+
+	enterName(d, "*.txt; *.MD") // Patterns ignore case, like DOS
+	if !reflect.DeepEqual(d.Patterns, []string{"*.txt", "*.MD"}) {
+		t.Errorf("patterns are %q", d.Patterns)
+	}
+	if files := labels(d.files); !reflect.DeepEqual(files, []string{"b.TXT", "c.md"}) {
+		t.Errorf("files are %q", files)
+	}
+	if d.input.Text != "" {
+		t.Errorf("file name is %q after entering patterns", d.input.Text)
+	}
+}
+
+func TestFileDialogChangeDir(t *testing.T) {
+	d := &FileDialog{Roots: []FileRoot{{Name: "/", FS: testFS}}}
+	s, closed := pushTestDialog(d)
+
+	enterName(d, "sub/deep")
+	if d.Dir != "sub/deep" {
+		t.Fatalf("directory is %q", d.Dir)
+	}
+	if dirs := labels(d.dirs); !reflect.DeepEqual(dirs, []string{".."}) {
+		t.Errorf("directories are %q", dirs)
+	}
+	d.openDir(0) // ..
+	if d.Dir != "sub" {
+		t.Fatalf("directory is %q after opening ..", d.Dir)
+	}
+	if files := labels(d.files); !reflect.DeepEqual(files, []string{"x.go"}) {
+		t.Errorf("files are %q", files)
+	}
+
+	enterName(d, "/c.md") // Relative to the root
+	if *closed != "c.md" || s.HasModal() {
+		t.Errorf("dialog closed with %q", *closed)
+	}
+}
+
+func TestFileDialogMissingFile(t *testing.T) {
+	d := &FileDialog{Roots: []FileRoot{{Name: "/", FS: testFS}}}
+	s, closed := pushTestDialog(d)
+	enterName(d, "missing.go")
+	if *closed != "" || len(s.modals) != 2 {
+		t.Errorf("opening a missing file closed with %q and %d modals", *closed, len(s.modals))
+	}
+
+	d = &FileDialog{Mode: FileSave, Roots: []FileRoot{{Name: "/", FS: testFS}}, Dir: "sub"}
+	_, closed = pushTestDialog(d)
+	enterName(d, "new.go")
+	if *closed != "sub/new.go" {
+		t.Errorf("saving a new file closed with %q", *closed)
+	}
+}
+
+func TestFileDialogNoRoots(t *testing.T) {
+	for _, mode := range []FileDialogMode{FileOpen, FileSave} {
+		d := &FileDialog{Mode: mode}
+		s, closed := pushTestDialog(d)
+		enterName(d, "a.go")
+		if *closed != "" || len(s.modals) != 2 {
+			t.Errorf("mode %d closed with %q and %d modals", mode, *closed, len(s.modals))
+		}
+	}
+
+	// A root without a file system is as good as none
+	d := &FileDialog{Roots: []FileRoot{{Name: "A:"}}}
+	s, closed := pushTestDialog(d)
+	enterName(d, "a.go")
+	if *closed != "" || len(s.modals) != 2 {
+		t.Errorf("closed with %q and %d modals", *closed, len(s.modals))
+	}
+}
+
+func TestFileDialogRoots(t *testing.T) {
+	other := fstest.MapFS{"notes.txt": {}}
+	d := &FileDialog{Roots: []FileRoot{
+		{Name: "A:", FS: testFS},
+		{Name: "B:", Path: filepath.FromSlash("/mnt/b"), FS: other},
+	}}
+	_, closed := pushTestDialog(d)
+	dirs := labels(d.dirs)
+	if !reflect.DeepEqual(dirs, []string{"sub/", "[A:]", "[B:]"}) {
+		t.Fatalf("directories are %q", dirs)
+	}
+
+	d.openDir(2)
+	if d.Root != 1 || d.Dir != "." {
+		t.Fatalf("root is %d and directory is %q", d.Root, d.Dir)
+	}
+	if files := labels(d.files); !reflect.DeepEqual(files, []string{"notes.txt"}) {
+		t.Errorf("files are %q", files)
+	}
+	enterName(d, "notes.txt")
+	if want := filepath.Join(filepath.FromSlash("/mnt/b"), "notes.txt"); *closed != want {
+		t.Errorf("dialog closed with %q, expected %q", *closed, want)
+	}
+}
@@ -1,8 +1,9 @@
 module github.com/fivemoreminix/dos
 
-go 1.14
+go 1.16
 
 require (
+	github.com/creack/pty v1.1.18
 	github.com/gdamore/tcell/v2 v2.4.0
 	github.com/mattn/go-runewidth v0.0.13
 	github.com/zyedidia/rope v0.0.0-20210616205215-37fbf22eab3a
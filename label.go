@@ -71,6 +71,11 @@ func (l *Label) HandleKey(_ *tcell.EventKey) bool {
 
 func (l *Label) SetFocused(_ bool) {}
 
+// TakeDamage reports no damage, as a Label handles no keys.
+func (l *Label) TakeDamage() ([]Rect, bool) {
+	return nil, false
+}
+
 func (l *Label) DisplaySize(boundsW, boundsH int) (w, h int) {
 	rect := Rect{0, 0, boundsW, boundsH}
 	if l.WrapLen > 0 {
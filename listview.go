@@ -0,0 +1,388 @@
+package dos
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// A ListSource provides the items of a ListView by their index, so the items
+// do not have to be kept in memory as widgets.
+type ListSource interface {
+	Len() int
+	// Label returns the text of the item, which is drawn and searched when the
+	// user types.
+	Label(i int) string
+}
+
+// A ListDrawer is a ListSource that draws its own items, instead of their
+// labels. The screen is clipped to the rect of the item.
+type ListDrawer interface {
+	ListSource
+	DrawItem(i int, rect Rect, style tcell.Style, s tcell.Screen)
+}
+
+// A StringList is a ListSource of strings.
+type StringList []string
+
+func (l StringList) Len() int {
+	return len(l)
+}
+
+func (l StringList) Label(i int) string {
+	return l[i]
+}
+
+// doubleClickTime is the most time between two clicks of a double-click.
+const doubleClickTime = 500 * time.Millisecond
+
+// typeAheadTime is the most time between keys typed to search a ListView, or
+// the search starts over.
+const typeAheadTime = time.Second
+
+// A ListView shows a scrolling list of the items of its Source, with one row
+// per item. The cursor is on one of the items, which is also the selection
+// unless MultiSelect is true. Then Space or Ctrl and a click select the item
+// at the cursor, Shift selects all items from the last one selected to the
+// cursor, and Ctrl+A selects every item.
+//
+// The cursor is moved with the arrow keys, PgUp, PgDn, Home and End, or by
+// typing the first letters of the label of an item. Enter or double-clicking
+// an item activates it.
+type ListView struct {
+	Source         ListSource
+	MultiSelect    bool
+	NormalStyle    tcell.Style
+	CursorStyle    tcell.Style // Style of the item at the cursor when focused
+	SelectedStyle  tcell.Style // If SelectedStyle is the default style, then NormalStyle is reversed.
+	ScrollbarStyle tcell.Style
+	OnSelect       func(i int) // Called when the cursor moves to the item
+	OnActivate     func(i int)
+
+	cursor       int
+	anchor       int // Where a range selected with Shift starts
+	selected     map[int]bool
+	scroll       int  // Index of the first visible item
+	rect         Rect // Where the ListView was last drawn
+	followCursor bool
+	focused      bool
+	pressed      bool // The primary mouse button is down
+	lastClick    time.Time
+	lastClicked  int
+	search       string
+	lastSearch   time.Time
+	damage       damage
+}
+
+// Len returns the number of items, or zero if there is no Source.
+func (l *ListView) Len() int {
+	if l.Source == nil {
+		return 0
+	}
+	return l.Source.Len()
+}
+
+// SetSource replaces the items, and moves the cursor to the first one.
+func (l *ListView) SetSource(source ListSource) {
+	l.Source = source
+	l.cursor, l.anchor, l.scroll = 0, 0, 0
+	l.selected = nil
+	l.lastClick = time.Time{}
+}
+
+// Cursor returns the index of the item at the cursor.
+func (l *ListView) Cursor() int {
+	return l.cursor
+}
+
+// SetCursor moves the cursor to the item, and scrolls to show it.
+func (l *ListView) SetCursor(i int) {
+	if l.Len() == 0 {
+		return
+	}
+	i = Clamp(i, 0, l.Len()-1)
+	l.followCursor = true
+	if i != l.cursor {
+		l.cursor = i
+		if l.OnSelect != nil {
+			l.OnSelect(i)
+			l.damage.add() // The callback could change anything
+		}
+	}
+}
+
+// IsSelected returns true if the item is selected.
+func (l *ListView) IsSelected(i int) bool {
+	if !l.MultiSelect {
+		return i == l.cursor && i < l.Len()
+	}
+	return l.selected[i]
+}
+
+// SetSelected selects or deselects the item, if MultiSelect is true.
+func (l *ListView) SetSelected(i int, selected bool) {
+	if !l.MultiSelect {
+		return
+	}
+	if selected {
+		if l.selected == nil {
+			l.selected = make(map[int]bool)
+		}
+		l.selected[i] = true
+	} else {
+		delete(l.selected, i)
+	}
+}
+
+// Selection returns the indices of the selected items in order.
+func (l *ListView) Selection() []int {
+	if !l.MultiSelect {
+		if l.Len() == 0 {
+			return nil
+		}
+		return []int{l.cursor}
+	}
+	selection := make([]int, 0, len(l.selected))
+	for i := range l.selected {
+		selection = append(selection, i)
+	}
+	sort.Ints(selection)
+	return selection
+}
+
+// ClearSelection deselects every item.
+func (l *ListView) ClearSelection() {
+	l.selected = nil
+}
+
+// selectRange selects only the items from the anchor to the cursor.
+func (l *ListView) selectRange() {
+	l.selected = nil
+	for i := Min(l.anchor, l.cursor); i <= Max(l.anchor, l.cursor); i++ {
+		l.SetSelected(i, true)
+	}
+}
+
+// moveCursor moves the cursor to the item, selecting the range from the
+// anchor if shift is true.
+func (l *ListView) moveCursor(i int, shift bool) {
+	l.SetCursor(i)
+	if l.MultiSelect && shift {
+		l.selectRange()
+	} else {
+		l.anchor = l.cursor
+	}
+}
+
+// typeAhead moves the cursor to the next item starting with the text typed so
+// far.
+func (l *ListView) typeAhead(r rune, when time.Time) {
+	if when.Sub(l.lastSearch) > typeAheadTime {
+		l.search = ""
+	}
+	l.lastSearch = when
+	l.search += strings.ToLower(string(r))
+	start := l.cursor + 1 // A new search finds the next item
+	if len([]rune(l.search)) > 1 {
+		start = l.cursor // Typing more letters may still match the cursor
+	}
+	n := l.Len()
+	for j := 0; j < n; j++ {
+		i := (start + j) % n
+		if strings.HasPrefix(strings.ToLower(l.Source.Label(i)), l.search) {
+			l.moveCursor(i, false)
+			return
+		}
+	}
+}
+
+func (l *ListView) activate(i int) {
+	if i < l.Len() && l.OnActivate != nil {
+		l.OnActivate(i)
+		l.damage.add()
+	}
+}
+
+func (l *ListView) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
+	posX, posY := ev.Position()
+	pressed := ev.Buttons()&tcell.ButtonPrimary != 0
+	wasPressed := l.pressed
+	l.pressed = pressed
+	if !currentRect.HasPoint(posX, posY) {
+		return false
+	}
+	n := l.Len()
+	maxScroll := Max(n-currentRect.H, 0)
+	switch {
+	case ev.Buttons()&tcell.WheelUp != 0:
+		l.scroll = Clamp(l.scroll-3, 0, maxScroll)
+		l.followCursor = false
+	case ev.Buttons()&tcell.WheelDown != 0:
+		l.scroll = Clamp(l.scroll+3, 0, maxScroll)
+		l.followCursor = false
+	case pressed && !wasPressed:
+		row := posY - currentRect.Y
+		if n > currentRect.H && posX == currentRect.X+currentRect.W-1 { // Scrollbar
+			thumbPos, length := thumb(currentRect.H-2, l.scroll, maxScroll, currentRect.H, n)
+			switch {
+			case row == 0:
+				l.scroll--
+			case row == currentRect.H-1:
+				l.scroll++
+			case row-1 < thumbPos:
+				l.scroll -= currentRect.H
+			case row-1 >= thumbPos+length:
+				l.scroll += currentRect.H
+			}
+			l.scroll = Clamp(l.scroll, 0, maxScroll)
+			l.followCursor = false
+			break
+		}
+		i := l.scroll + row
+		if i >= n {
+			break
+		}
+		mods := ev.Modifiers()
+		switch {
+		case l.MultiSelect && mods&tcell.ModCtrl != 0:
+			l.SetCursor(i)
+			l.anchor = i
+			l.SetSelected(i, !l.IsSelected(i))
+		case l.MultiSelect && mods&tcell.ModShift != 0:
+			l.moveCursor(i, true)
+		default:
+			l.moveCursor(i, false)
+			if l.MultiSelect {
+				l.ClearSelection()
+				l.SetSelected(i, true)
+			}
+			if i == l.lastClicked && ev.When().Sub(l.lastClick) <= doubleClickTime {
+				l.lastClick = time.Time{} // A third click is not another double-click
+				l.activate(i)
+				break
+			}
+			l.lastClick, l.lastClicked = ev.When(), i
+		}
+	default: // Moves, drags and releases do nothing
+		return false
+	}
+	l.SetFocused(true)
+	return true
+}
+
+func (l *ListView) HandleKey(ev *tcell.EventKey) bool {
+	if !l.focused || l.Len() == 0 {
+		return false
+	}
+	shift := ev.Modifiers()&tcell.ModShift != 0
+	page := Max(l.rect.H-1, 1)
+	switch ev.Key() {
+	case tcell.KeyUp:
+		l.moveCursor(l.cursor-1, shift)
+	case tcell.KeyDown:
+		l.moveCursor(l.cursor+1, shift)
+	case tcell.KeyPgUp:
+		l.moveCursor(l.cursor-page, shift)
+	case tcell.KeyPgDn:
+		l.moveCursor(l.cursor+page, shift)
+	case tcell.KeyHome:
+		l.moveCursor(0, shift)
+	case tcell.KeyEnd:
+		l.moveCursor(l.Len()-1, shift)
+	case tcell.KeyEnter:
+		l.activate(l.cursor)
+	case tcell.KeyCtrlA:
+		if !l.MultiSelect {
+			return false
+		}
+		l.anchor = 0
+		for i := 0; i < l.Len(); i++ {
+			l.SetSelected(i, true)
+		}
+	case tcell.KeyRune:
+		if ev.Modifiers()&(tcell.ModCtrl|tcell.ModAlt) != 0 {
+			return false
+		}
+		searching := ev.When().Sub(l.lastSearch) <= typeAheadTime
+		if ev.Rune() == ' ' && l.MultiSelect && !searching {
+			l.anchor = l.cursor
+			l.SetSelected(l.cursor, !l.IsSelected(l.cursor))
+		} else {
+			l.typeAhead(ev.Rune(), ev.When())
+		}
+	default:
+		return false
+	}
+	l.damage.add(l.rect)
+	return true
+}
+
+// TakeDamage reports the ListView after it handled a key, or the whole screen
+// if OnSelect or OnActivate were called.
+func (l *ListView) TakeDamage() ([]Rect, bool) {
+	return l.damage.take()
+}
+
+func (l *ListView) SetFocused(b bool) {
+	l.focused = b
+}
+
+// FocusRect returns the row of the cursor if the ListView is focused.
+func (l *ListView) FocusRect(rect Rect) (Rect, bool) {
+	row := l.cursor - l.scroll
+	if !l.focused || row < 0 || row >= rect.H {
+		return Rect{}, false
+	}
+	return Rect{rect.X, rect.Y + row, rect.W, 1}, true
+}
+
+func (l *ListView) DisplaySize(boundsW, boundsH int) (w, h int) {
+	return boundsW, boundsH
+}
+
+func (l *ListView) Draw(rect Rect, s tcell.Screen) {
+	l.rect = rect
+	n := l.Len()
+	l.cursor = Clamp(l.cursor, 0, Max(n-1, 0))
+	if l.followCursor { // Keep the cursor visible
+		l.followCursor = false
+		if l.cursor < l.scroll {
+			l.scroll = l.cursor
+		} else if l.cursor >= l.scroll+rect.H {
+			l.scroll = l.cursor - rect.H + 1
+		}
+	}
+	l.scroll = Clamp(l.scroll, 0, Max(n-rect.H, 0))
+
+	itemW := rect.W
+	if n > rect.H && rect.W > 1 {
+		itemW--
+		drawScrollbar(rect.X+itemW, rect.Y, 0, 1, rect.H, l.scroll, n-rect.H, n, '▲', '▼', l.ScrollbarStyle, s)
+	}
+	selectedStyle := l.SelectedStyle
+	if selectedStyle == tcell.StyleDefault {
+		selectedStyle = l.NormalStyle.Reverse(true)
+	}
+	drawer, _ := l.Source.(ListDrawer)
+
+	DrawRect(Rect{rect.X, rect.Y, itemW, rect.H}, ' ', l.NormalStyle, s)
+	for row := 0; row < rect.H && l.scroll+row < n; row++ {
+		i := l.scroll + row
+		style := l.NormalStyle
+		if i == l.cursor && l.focused {
+			style = l.CursorStyle
+		} else if l.IsSelected(i) {
+			style = selectedStyle
+		}
+		itemRect := Rect{rect.X, rect.Y + row, itemW, 1}
+		DrawRect(itemRect, ' ', style, s)
+		if drawer != nil {
+			drawer.DrawItem(i, itemRect, style, NewClipScreen(s, itemRect))
+		} else {
+			DrawString(rect.X+1, rect.Y+row, runewidth.Truncate(l.Source.Label(i), itemW-1, "…"), style, s)
+		}
+	}
+}
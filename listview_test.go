@@ -0,0 +1,136 @@
+package dos
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var listRect = Rect{0, 0, 20, 5}
+
+// newTestList returns a focused ListView of the items, drawn in listRect.
+func newTestList(t *testing.T, multiSelect bool, items ...string) *ListView {
+	l := &ListView{Source: StringList(items), MultiSelect: multiSelect}
+	l.SetFocused(true)
+	l.Draw(listRect, newTestScreen(t, listRect.W, listRect.H))
+	return l
+}
+
+// click presses and releases the primary button on the row of the ListView.
+func click(l *ListView, row int, mods tcell.ModMask) {
+	l.HandleMouse(listRect, tcell.NewEventMouse(1, row, tcell.ButtonPrimary, mods))
+	l.HandleMouse(listRect, tcell.NewEventMouse(1, row, tcell.ButtonNone, mods))
+}
+
+func TestListViewSelection(t *testing.T) {
+	down := tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	shiftDown := tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModShift)
+	space := tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone)
+	tests := []struct {
+		name   string
+		do     func(l *ListView)
+		cursor int
+		want   []int
+	}{
+		{"shift keys", func(l *ListView) {
+			l.HandleKey(down)
+			l.HandleKey(shiftDown)
+			l.HandleKey(shiftDown)
+		}, 3, []int{1, 2, 3}},
+		{"shift back over the anchor", func(l *ListView) {
+			l.SetCursor(2)
+			l.HandleKey(space)
+			l.HandleKey(tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModShift))
+			l.HandleKey(tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModShift))
+		}, 0, []int{0, 1, 2}},
+		{"space", func(l *ListView) {
+			l.HandleKey(space)
+			l.HandleKey(down)
+			l.HandleKey(down)
+			l.HandleKey(space)
+		}, 2, []int{0, 2}},
+		{"ctrl click", func(l *ListView) {
+			click(l, 1, tcell.ModNone)
+			click(l, 3, tcell.ModCtrl)
+			click(l, 4, tcell.ModCtrl)
+			click(l, 1, tcell.ModCtrl) // Deselects
+		}, 1, []int{3, 4}},
+		{"shift click from ctrl click", func(l *ListView) {
+			click(l, 0, tcell.ModNone)
+			click(l, 2, tcell.ModCtrl)
+			click(l, 4, tcell.ModShift)
+		}, 4, []int{2, 3, 4}},
+		{"click clears", func(l *ListView) {
+			l.HandleKey(tcell.NewEventKey(tcell.KeyCtrlA, 0, tcell.ModCtrl))
+			click(l, 3, tcell.ModNone)
+		}, 3, []int{3}},
+		{"ctrl+a", func(l *ListView) {
+			l.HandleKey(tcell.NewEventKey(tcell.KeyCtrlA, 0, tcell.ModCtrl))
+		}, 0, []int{0, 1, 2, 3, 4}},
+	}
+	for _, test := range tests {
+		l := newTestList(t, true, "a", "b", "c", "d", "e")
+		test.do(l)
+		if l.Cursor() != test.cursor {
+			t.Errorf("%s: cursor is %d, expected %d", test.name, l.Cursor(), test.cursor)
+		}
+		if got := l.Selection(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: selection is %v, expected %v", test.name, got, test.want)
+		}
+	}
+
+	l := newTestList(t, false, "a", "b", "c")
+	l.HandleKey(shiftDown)
+	l.HandleKey(space)
+	if got := l.Selection(); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("selection without MultiSelect is %v", got)
+	}
+}
+
+func TestListViewTypeAhead(t *testing.T) {
+	l := newTestList(t, false, "apple", "Banana", "blueberry", "cherry", "avocado")
+	start := time.Now()
+	tests := []struct {
+		r      rune
+		after  time.Duration
+		cursor int
+	}{
+		{'b', 0, 1},
+		{'l', 100 * time.Millisecond, 2},  // "bl"
+		{'b', 3 * time.Second, 1},         // A new search from the cursor wraps around
+		{'a', 3500 * time.Millisecond, 1}, // "ba" still matches the cursor
+		{'a', 5 * time.Second, 4},         // The next item starting with 'a'
+		{'a', 7 * time.Second, 0},         // Again, wrapping around
+		{'p', 7100 * time.Millisecond, 0}, // "ap"
+		{'x', 7200 * time.Millisecond, 0}, // No match does not move the cursor
+	}
+	for i, test := range tests {
+		l.typeAhead(test.r, start.Add(test.after))
+		if l.Cursor() != test.cursor {
+			t.Errorf("key %d (%q): cursor is %d, expected %d", i, test.r, l.Cursor(), test.cursor)
+		}
+	}
+}
+
+func TestListViewMouseFocus(t *testing.T) {
+	l := &ListView{Source: StringList{"a", "b"}}
+	if l.HandleMouse(listRect, tcell.NewEventMouse(1, 1, tcell.ButtonNone, tcell.ModNone)) || l.focused {
+		t.Error("moving the mouse over the ListView handled it")
+	}
+	if !l.HandleMouse(listRect, tcell.NewEventMouse(1, 1, tcell.WheelDown, tcell.ModNone)) || !l.focused {
+		t.Error("the wheel did not focus the ListView")
+	}
+	l.SetFocused(false)
+	if !l.HandleMouse(listRect, tcell.NewEventMouse(1, 1, tcell.ButtonPrimary, tcell.ModNone)) || !l.focused {
+		t.Error("a press did not focus the ListView")
+	}
+	l.SetFocused(false)
+	if l.HandleMouse(listRect, tcell.NewEventMouse(1, 1, tcell.ButtonPrimary, tcell.ModNone)) || l.focused {
+		t.Error("dragging over the ListView handled it")
+	}
+	if l.HandleMouse(listRect, tcell.NewEventMouse(1, 1, tcell.ButtonNone, tcell.ModNone)) || l.focused {
+		t.Error("releasing the button handled it")
+	}
+}
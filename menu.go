@@ -11,16 +11,57 @@ const (
 	MenuItemAction MenuItemType = iota
 	MenuItemSubmenu
 	MenuItemSeparator
+	MenuItemCheckbox // An action that toggles the Checked bool
+	MenuItemRadio    // An action that sets the RadioGroup to its RadioValue
 )
 
 // A MenuItem is a selectable option inside a Menu. If the Type is
 // MenuItemAction, then only the Action field should be accessed. Likewise, if
 // the Type is MenuItemSubmenu, then only the Submenu field should be accessed.
+//
+// A MenuItemCheckbox is checked when its Checked bool is true, and activating
+// it toggles the bool before its Action is called. Items of the type
+// MenuItemRadio sharing a RadioGroup form a group where only the item with the
+// RadioValue in the group is checked, and activating an item sets the group to
+// its value. Disabled items are drawn with the Menu's DisabledStyle, and
+// cannot be selected or activated. OnUpdate is called before the item is drawn
+// or its shortcut is used, to change the item, like to disable it when its
+// action is not possible.
+//
+// A '&' in the Title marks the next rune as the item's mnemonic, which is drawn
+// underlined and activates the item when typed in the open Menu, like "&Save".
+// The Shortcut of an action, like "Ctrl+S", is drawn on the right side of the
+// item, and its key combination activates the item even when the Menu is
+// closed. See MatchesShortcut for how it is written.
 type MenuItem struct {
-	Title   string
-	Type    MenuItemType
-	Action  func()
-	Submenu *Menu
+	Title    string
+	Shortcut string
+	Type     MenuItemType
+	Action   func()
+	Submenu  *Menu
+
+	Checked    *bool // Used only if Type is MenuItemCheckbox
+	RadioGroup *int  // Used only if Type is MenuItemRadio
+	RadioValue int   // Used only if Type is MenuItemRadio
+	Disabled   bool
+	OnUpdate   func(item *MenuItem)
+}
+
+// IsChecked returns true if the item is a checkbox or radio item that is
+// checked.
+func (item *MenuItem) IsChecked() bool {
+	switch item.Type {
+	case MenuItemCheckbox:
+		return item.Checked != nil && *item.Checked
+	case MenuItemRadio:
+		return item.RadioGroup != nil && *item.RadioGroup == item.RadioValue
+	}
+	return false
+}
+
+// isSelectable returns true if the item can be selected and activated.
+func (item *MenuItem) isSelectable() bool {
+	return item.Type != MenuItemSeparator && !item.Disabled
 }
 
 // A Menu contains a list of selectable items the user may either click or
@@ -28,79 +69,250 @@ type MenuItem struct {
 // MenuItem, which can be an action or expand into another menu, known as a
 // submenu. This widget will handle any events it receives, so do not pass
 // an event to the Menu if it is not focused or otherwise visible.
+//
+// A Menu too high for the bounds of DisplaySize shows only the items that fit,
+// and scrolls with the selection or the mouse wheel. Arrows on its top and
+// bottom rows show that there are more items.
+//
+// A submenu opens to the right of its item, or to the left if there is no room
+// on the screen, when the item is clicked or Right or Enter is pressed. Left
+// and Escape close it. Submenus can be nested to any depth, and events are
+// passed to the deepest open submenu first.
 type Menu struct {
 	Items          []MenuItem
 	Decorated      bool           // Whether to draw a styled box around the Menu
 	Decoration     *BoxDecoration // Used only if Decorated is true
 	NormalStyle    tcell.Style
 	SelectionStyle tcell.Style
+	DisabledStyle  tcell.Style // If DisabledStyle is the default style, then NormalStyle is dimmed.
 	Selected       int
-	itemsExpanded  bool // True if the Selected submenu should be visible
+	// OnAction is called after an action of the Menu, or of any of its
+	// submenus, is activated, so the Menu can be closed.
+	OnAction      func()
+	itemsExpanded bool // True if the Selected submenu should be visible
+	submenuRect   Rect // Where the open submenu was last drawn
+	scroll        int  // Index of the first visible item
 }
 
+// ActivateItem calls the action of the item at the index, or opens its submenu.
+// Does nothing if the item is disabled.
 func (m *Menu) ActivateItem(idx int) {
-	switch item := m.Items[idx]; item.Type {
-	case MenuItemAction:
+	item := m.Items[idx]
+	if item.Disabled {
+		return
+	}
+	switch item.Type {
+	case MenuItemCheckbox:
+		if item.Checked != nil {
+			*item.Checked = !*item.Checked
+		}
+	case MenuItemRadio:
+		if item.RadioGroup != nil {
+			*item.RadioGroup = item.RadioValue
+		}
+	}
+	switch item.Type {
+	case MenuItemAction, MenuItemCheckbox, MenuItemRadio:
+		m.itemsExpanded = false
 		if item.Action != nil {
 			item.Action()
 		}
+		if m.OnAction != nil {
+			m.OnAction()
+		}
 	case MenuItemSubmenu:
+		if item.Submenu != nil {
+			m.itemsExpanded = true
+			item.Submenu.itemsExpanded = false
+			item.Submenu.update()
+			item.Submenu.Selected = item.Submenu.firstSelectable()
+			item.Submenu.OnAction = m.OnAction
+		}
 	case MenuItemSeparator:
 	}
 }
 
+// openSubmenu returns the open submenu, or nil if none is open.
+func (m *Menu) openSubmenu() *Menu {
+	if m.itemsExpanded && m.Selected >= 0 && m.Selected < len(m.Items) && m.Items[m.Selected].Type == MenuItemSubmenu {
+		return m.Items[m.Selected].Submenu
+	}
+	return nil
+}
+
+// Collapse closes any open submenus.
+func (m *Menu) Collapse() {
+	if sub := m.openSubmenu(); sub != nil {
+		sub.Collapse()
+	}
+	m.itemsExpanded = false
+}
+
+// firstSelectable returns the index of the first item that is not a separator
+// or disabled.
+func (m *Menu) firstSelectable() int {
+	for i := range m.Items {
+		if m.Items[i].isSelectable() {
+			return i
+		}
+	}
+	return 0
+}
+
+// moveSelection selects the next selectable item in the direction, wrapping
+// around. Nothing changes if there is no other selectable item.
+func (m *Menu) moveSelection(direction int) {
+	for i, idx := 0, m.Selected; i < len(m.Items); i++ {
+		idx = (idx + direction + len(m.Items)) % len(m.Items)
+		if m.Items[idx].isSelectable() {
+			m.Selected = idx
+			return
+		}
+	}
+}
+
+// update calls the OnUpdate callback of each item.
+func (m *Menu) update() {
+	for i := range m.Items {
+		if m.Items[i].OnUpdate != nil {
+			m.Items[i].OnUpdate(&m.Items[i])
+		}
+	}
+}
+
+// hasChecks returns true if the Menu has checkbox or radio items, which need a
+// column for their check marks.
+func (m *Menu) hasChecks() bool {
+	for i := range m.Items {
+		if t := m.Items[i].Type; t == MenuItemCheckbox || t == MenuItemRadio {
+			return true
+		}
+	}
+	return false
+}
+
+// visibleRows returns the number of items shown in a rect of the height.
+func (m *Menu) visibleRows(height int) int {
+	if m.Decorated {
+		height -= 2
+	}
+	return Clamp(height, 0, len(m.Items))
+}
+
+// scrollToSelected changes the scroll so the Selected item is in the rows.
+func (m *Menu) scrollToSelected(rows int) {
+	if m.Selected < m.scroll {
+		m.scroll = m.Selected
+	} else if m.Selected >= m.scroll+rows {
+		m.scroll = m.Selected - rows + 1
+	}
+	m.scroll = Clamp(m.scroll, 0, Max(len(m.Items)-rows, 0))
+}
+
+// scrollBy scrolls the rows by delta items, and moves the selection to the
+// closest selectable item still in the rows.
+func (m *Menu) scrollBy(delta, rows int) {
+	m.scroll = Clamp(m.scroll+delta, 0, Max(len(m.Items)-rows, 0))
+	if m.Selected < m.scroll {
+		for i := m.scroll; i < m.scroll+rows; i++ {
+			if m.Items[i].isSelectable() {
+				m.Selected = i
+				break
+			}
+		}
+	} else if m.Selected >= m.scroll+rows {
+		for i := m.scroll + rows - 1; i >= m.scroll; i-- {
+			if m.Items[i].isSelectable() {
+				m.Selected = i
+				break
+			}
+		}
+	}
+}
+
 func (m *Menu) HandleMouse(rect Rect, ev *tcell.EventMouse) bool {
-	if ev.Buttons() == tcell.ButtonPrimary {
-		sizeW, sizeH := m.DisplaySize(0, 0)
-		posX, posY := ev.Position()
-		// Check if user clicked any part of the menu (including border)
-		if posX >= rect.X && posX < rect.X+sizeW && posY >= rect.Y && posY < rect.Y+sizeH {
-			offsetX, offsetY := 0, 0
-			if m.Decorated { // If we have a border around the menu
-				sizeW -= 2
-				offsetX, offsetY = 1, 1
+	if sub := m.openSubmenu(); sub != nil && sub.HandleMouse(m.submenuRect, ev) {
+		return true
+	}
+	sizeW, sizeH := m.DisplaySize(rect.W, rect.H)
+	posX, posY := ev.Position()
+	// Check if the event is on any part of the menu (including border)
+	if !(Rect{rect.X, rect.Y, sizeW, sizeH}).HasPoint(posX, posY) {
+		return false
+	}
+	rows := m.visibleRows(sizeH)
+	switch {
+	case ev.Buttons()&tcell.WheelUp != 0:
+		m.Collapse()
+		m.scrollBy(-1, rows)
+		return true
+	case ev.Buttons()&tcell.WheelDown != 0:
+		m.Collapse()
+		m.scrollBy(1, rows)
+		return true
+	case ev.Buttons() == tcell.ButtonPrimary:
+		offsetX, offsetY := 0, 0
+		if m.Decorated { // If we have a border around the menu
+			sizeW -= 2
+			offsetX, offsetY = 1, 1
+			// The arrows on the borders scroll
+			if posY == rect.Y {
+				m.scrollBy(-1, rows)
+			} else if posY == rect.Y+sizeH-1 {
+				m.scrollBy(1, rows)
 			}
-			// Check that the click occurred between the borders of the menu
-			if posX >= rect.X+offsetX && posX < rect.X+offsetX+sizeW {
-				for i := 0; i < len(m.Items); i++ {
-					if posY == rect.Y+i+offsetY {
-						m.Selected = i
-						m.ActivateItem(i)
-					}
-				}
+		}
+		// Check that the click occurred between the borders of the menu
+		row := posY - rect.Y - offsetY
+		if posX >= rect.X+offsetX && posX < rect.X+offsetX+sizeW && row >= 0 && row < rows {
+			if i := m.scroll + row; m.Items[i].isSelectable() {
+				m.Collapse()
+				m.Selected = i
+				m.ActivateItem(i)
 			}
-			return true // User clicked somewhere in the menu
 		}
+		return true // User clicked somewhere in the menu
 	}
 	return false
 }
 
 func (m *Menu) HandleKey(ev *tcell.EventKey) bool {
-	// TODO: support submenus in Menu
+	if sub := m.openSubmenu(); sub != nil {
+		if sub.HandleKey(ev) {
+			return true
+		}
+		switch ev.Key() {
+		case tcell.KeyLeft, tcell.KeyEscape:
+			m.Collapse()
+			return true
+		}
+		return false // Like Right on an action, which moves to the next menu
+	}
 	if m.Items != nil && len(m.Items) > 0 {
 		switch ev.Key() {
-		case tcell.KeyUp:
-			for {
-				m.Selected--
-				if m.Selected < 0 {
-					m.Selected = len(m.Items) - 1
-				}
-				if m.Items[m.Selected].Type != MenuItemSeparator {
-					break // TODO: Loop has the potential to block forever
-				}
+		case tcell.KeyRight:
+			if m.Items[m.Selected].Type != MenuItemSubmenu {
+				return false
 			}
+			m.ActivateItem(m.Selected)
+		case tcell.KeyUp:
+			m.moveSelection(-1)
 		case tcell.KeyDown:
-			for {
-				m.Selected++
-				if m.Selected >= len(m.Items) {
-					m.Selected = 0
-				}
-				if m.Items[m.Selected].Type != MenuItemSeparator {
-					break
-				}
-			}
+			m.moveSelection(1)
 		case tcell.KeyEnter:
 			m.ActivateItem(m.Selected)
+		case tcell.KeyRune:
+			if ev.Modifiers()&tcell.ModCtrl != 0 {
+				return false
+			}
+			for i := range m.Items {
+				if m.Items[i].isSelectable() && matchesMnemonic(MnemonicOf(m.Items[i].Title), ev) {
+					m.Selected = i
+					m.ActivateItem(i)
+					return true
+				}
+			}
+			return false
 		default:
 			return false
 		}
@@ -109,27 +321,76 @@ func (m *Menu) HandleKey(ev *tcell.EventKey) bool {
 	return false
 }
 
+// HandleShortcut activates the action of the Menu, or of any of its submenus,
+// whose Shortcut matches the key event, even if the Menu is closed. Returns
+// true if an action was activated.
+func (m *Menu) HandleShortcut(ev *tcell.EventKey) bool {
+	for i := range m.Items {
+		switch item := &m.Items[i]; item.Type {
+		case MenuItemAction, MenuItemCheckbox, MenuItemRadio:
+			if item.Shortcut == "" || !MatchesShortcut(item.Shortcut, ev) {
+				continue
+			}
+			if item.OnUpdate != nil {
+				item.OnUpdate(item)
+			}
+			if !item.Disabled {
+				m.ActivateItem(i)
+				return true
+			}
+		case MenuItemSubmenu:
+			if item.Submenu != nil && item.Submenu.HandleShortcut(ev) {
+				m.Collapse()
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (m *Menu) SetFocused(bool) {}
 
-// DisplaySize for Menu returns the minimum size to show the Menu normally,
-// so it ignores the input boundary values.
-func (m *Menu) DisplaySize(int, int) (w int, h int) {
+// DisplaySize for Menu returns the minimum size to show every item, but no higher
+// than boundsH, so the Menu scrolls. The width ignores boundsW.
+func (m *Menu) DisplaySize(_, boundsH int) (w int, h int) {
 	// Find the widest item
 	widestItemWidth := 0
+	checks := m.hasChecks()
 	for i := range m.Items {
-		if width := runewidth.StringWidth(m.Items[i].Title); width > widestItemWidth {
+		width := mnemonicWidth(m.Items[i].Title)
+		if checks {
+			width += 2 // Room for the check mark
+		}
+		if m.Items[i].Shortcut != "" {
+			width += 2 + runewidth.StringWidth(m.Items[i].Shortcut) // Gap before the shortcut
+		}
+		if m.Items[i].Type == MenuItemSubmenu {
+			width += 2 // Room for the arrow
+		}
+		if width > widestItemWidth {
 			widestItemWidth = width
 		}
 	}
 	if m.Decorated {
-		return widestItemWidth + 2, len(m.Items) + 2
+		return widestItemWidth + 2, Min(len(m.Items)+2, boundsH)
 	} else {
-		return widestItemWidth, len(m.Items)
+		return widestItemWidth, Min(len(m.Items), boundsH)
 	}
 }
 
 func (m *Menu) Draw(rect Rect, s tcell.Screen) {
-	width, _ := m.DisplaySize(0, 0)
+	m.update()
+	width, height := m.DisplaySize(rect.W, rect.H)
+	rows := m.visibleRows(height)
+	m.scrollToSelected(rows)
+	checkWidth := 0
+	if m.hasChecks() {
+		checkWidth = 2
+	}
+	disabledStyle := m.DisabledStyle
+	if disabledStyle == tcell.StyleDefault {
+		disabledStyle = m.NormalStyle.Dim(true)
+	}
 	offsetX, offsetY := 0, 0
 	decoration := m.Decoration
 	if m.Decorated {
@@ -138,25 +399,167 @@ func (m *Menu) Draw(rect Rect, s tcell.Screen) {
 		if decoration == nil {
 			decoration = &DefaultBoxDecoration
 		}
-		DrawBox(rect, decoration, s)
+		DrawBox(Rect{rect.X, rect.Y, width, height}, decoration, s)
 	}
-	for i := 0; i < len(m.Items); i++ {
+	for row := 0; row < rows; row++ {
+		i := m.scroll + row
+		y := rect.Y + offsetY + row
 		style := m.NormalStyle
-		if i == m.Selected {
+		if m.Items[i].Disabled {
+			style = disabledStyle
+		} else if i == m.Selected {
 			style = m.SelectionStyle
 		}
 
 		if m.Items[i].Type == MenuItemSeparator && m.Decorated {
-			s.SetContent(rect.X, rect.Y+offsetY+i, decoration.JointL, nil, decoration.Style)
-			s.SetContent(rect.X+width-1, rect.Y+offsetY+i, decoration.JointR, nil, decoration.Style)
+			s.SetContent(rect.X, y, decoration.JointL, nil, decoration.Style)
+			s.SetContent(rect.X+width-1, y, decoration.JointR, nil, decoration.Style)
 			for col := 0; col < width-offsetX*2; col++ {
-				s.SetContent(rect.X+offsetX+col, rect.Y+offsetY+i, decoration.Hor, nil, decoration.Style)
+				s.SetContent(rect.X+offsetX+col, y, decoration.Hor, nil, decoration.Style)
 			}
 		} else {
 			for col := 0; col < width-offsetX*2; col++ {
-				s.SetContent(rect.X+offsetX+col, rect.Y+offsetY+i, ' ', nil, style)
+				s.SetContent(rect.X+offsetX+col, y, ' ', nil, style)
 			}
-			DrawString(rect.X+offsetX, rect.Y+offsetY+i, m.Items[i].Title, style, s)
+			if m.Items[i].IsChecked() {
+				check := '√'
+				if m.Items[i].Type == MenuItemRadio {
+					check = '•'
+				}
+				s.SetContent(rect.X+offsetX, y, check, nil, style)
+			}
+			DrawMnemonicString(rect.X+offsetX+checkWidth, y, m.Items[i].Title, style, s)
+			if m.Items[i].Type == MenuItemSubmenu {
+				s.SetContent(rect.X+width-offsetX-1, y, '►', nil, style)
+			} else if shortcut := m.Items[i].Shortcut; shortcut != "" {
+				DrawString(rect.X+width-offsetX-runewidth.StringWidth(shortcut), y, shortcut, style, s)
+			}
+		}
+	}
+
+	// Show that there are more items above or below
+	arrowX, topY, bottomY := rect.X+width-1, rect.Y, rect.Y+height-1
+	arrowStyle := m.NormalStyle
+	if m.Decorated {
+		arrowX = rect.X + width/2
+		arrowStyle = decoration.Style
+	}
+	if m.scroll > 0 {
+		s.SetContent(arrowX, topY, '▲', nil, arrowStyle)
+	}
+	if m.scroll+rows < len(m.Items) {
+		s.SetContent(arrowX, bottomY, '▼', nil, arrowStyle)
+	}
+
+	if sub := m.openSubmenu(); sub != nil {
+		m.submenuRect = m.placeSubmenu(rect, sub, s)
+		sub.Draw(m.submenuRect, s)
+		if m.Decorated && sub.Decorated {
+			m.joinSubmenu(rect, sub, s)
+		}
+	}
+}
+
+// placeSubmenu returns the rect of the open submenu of the Menu drawn in the
+// rect: beside the selected item, on the right if it fits on the screen. The
+// borders of decorated menus overlap.
+func (m *Menu) placeSubmenu(rect Rect, sub *Menu, s tcell.Screen) Rect {
+	width, _ := m.DisplaySize(rect.W, rect.H)
+	screenW, screenH := s.Size()
+	subW, subH := sub.DisplaySize(screenW, screenH)
+	overlap, offsetY := 0, 0
+	if m.Decorated {
+		offsetY = 1
+		if sub.Decorated {
+			overlap = 1
+		}
+	}
+	if sub.Decorated {
+		offsetY-- // Align the first item of the submenu with the selected item
+	}
+	x := rect.X + width - overlap
+	if x+subW > screenW && rect.X+overlap-subW >= 0 {
+		x = rect.X + overlap - subW // Open to the left
+	}
+	y := Max(Min(rect.Y+offsetY+m.Selected-m.scroll, screenH-subH), 0)
+	return Rect{x, y, subW, subH}
+}
+
+// Directions a line leaves a cell of a box, to find the rune joining boxes.
+const (
+	lineUp uint8 = 1 << iota
+	lineDown
+	lineLeft
+	lineRight
+)
+
+// borderLines returns the directions of the lines of the Menu's border at the
+// row y of its side, drawn in the rect, facing left or right.
+func (m *Menu) borderLines(rect Rect, y int, facingRight bool) uint8 {
+	_, height := m.DisplaySize(rect.W, rect.H)
+	inward := lineLeft
+	if facingRight {
+		inward = lineRight
+	}
+	switch i := y - rect.Y - 1 + m.scroll; {
+	case y == rect.Y:
+		return lineDown | inward
+	case y == rect.Y+height-1:
+		return lineUp | inward
+	case i >= 0 && i < len(m.Items) && m.Items[i].Type == MenuItemSeparator:
+		return lineUp | lineDown | inward
+	}
+	return lineUp | lineDown
+}
+
+// boxRunes returns the rune of the decoration for each combination of lines.
+func boxRunes(decoration *BoxDecoration) map[uint8]rune {
+	return map[uint8]rune{
+		lineUp | lineDown:                        decoration.Vert,
+		lineLeft | lineRight:                     decoration.Hor,
+		lineDown | lineRight:                     decoration.TL,
+		lineDown | lineLeft:                      decoration.TR,
+		lineUp | lineLeft:                        decoration.BR,
+		lineUp | lineRight:                       decoration.BL,
+		lineUp | lineDown | lineRight:            decoration.JointL,
+		lineUp | lineDown | lineLeft:             decoration.JointR,
+		lineDown | lineLeft | lineRight:          decoration.JointT,
+		lineUp | lineLeft | lineRight:            decoration.JointB,
+		lineUp | lineDown | lineLeft | lineRight: decoration.Cross,
+	}
+}
+
+// joinSubmenu draws joints where the border of the open submenu, already drawn
+// with its own submenus, overlaps the border of the Menu.
+func (m *Menu) joinSubmenu(rect Rect, sub *Menu, s tcell.Screen) {
+	decoration := m.Decoration
+	if decoration == nil {
+		decoration = &DefaultBoxDecoration
+	}
+	subDecoration := sub.Decoration
+	if subDecoration == nil {
+		subDecoration = &DefaultBoxDecoration
+	}
+	runes := boxRunes(decoration)
+	drawnLines := make(map[rune]uint8)
+	for lines, r := range boxRunes(subDecoration) {
+		drawnLines[r] = lines
+	}
+
+	_, height := m.DisplaySize(rect.W, rect.H)
+	subRect := m.submenuRect
+	right := subRect.X >= rect.X // Submenu opened to the right
+	x := subRect.X
+	if !right {
+		x = subRect.X + subRect.W - 1
+	}
+	for y := Max(rect.Y, subRect.Y); y < Min(rect.Y+height, subRect.Y+subRect.H); y++ {
+		drawn, _, _, _ := s.GetContent(x, y)
+		if drawnLines[drawn] == 0 {
+			continue // Covered by a deeper submenu
+		}
+		if r := runes[m.borderLines(rect, y, !right)|drawnLines[drawn]]; r != 0 {
+			s.SetContent(x, y, r, nil, decoration.Style)
 		}
 	}
 }
@@ -1,12 +1,12 @@
 package dos
 
 import (
-	"fmt"
 	"github.com/gdamore/tcell/v2"
-	"github.com/mattn/go-runewidth"
 )
 
-// A MenuBarItem is a Menu with an added Title field.
+// A MenuBarItem is a Menu with an added Title field. A '&' in the Title marks
+// its mnemonic, like in a MenuItem: Alt and the mnemonic open the Menu, as does
+// only the mnemonic when the MenuBar is focused.
 type MenuBarItem struct {
 	Title string
 	Menu
@@ -19,6 +19,8 @@ type MenuBar struct {
 	Selected       int
 	expanded       bool // Whether the user is expanding the menus currently
 	focused        bool // Whether to accept keyboard input and highlight selection
+	menuRect       Rect // Where the expanded menu was last drawn
+	damage         damage
 }
 
 // ItemRects returns a slice of Rects for each Menu's title that the user
@@ -29,7 +31,7 @@ func (m *MenuBar) ItemRects(rect Rect) []Rect {
 	rects := make([]Rect, len(m.Menus))
 	col := 1
 	for i := 0; i < len(m.Menus); i++ {
-		textWidth := runewidth.StringWidth(m.Menus[i].Title)
+		textWidth := mnemonicWidth(m.Menus[i].Title)
 		rects[i] = Rect{rect.X + col, rect.Y, textWidth + 2, 1}
 		col += textWidth + 2
 	}
@@ -41,21 +43,48 @@ func (m *MenuBar) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
 	if ev.Buttons()&tcell.ButtonPrimary != 0 {
 		for i := 0; i < len(rects); i++ {
 			if rects[i].HasPoint(ev.Position()) {
+				m.Menus[m.Selected].Collapse()
 				m.focused = true
 				m.Selected = i
-				m.expanded = true
+				m.expand()
 				return true
 			}
 		}
 	}
 	if m.expanded && len(m.Menus) > 0 {
-		rect := Rect{rects[m.Selected].X, rects[m.Selected].Y + 1, currentRect.W, currentRect.H}
-		m.Menus[m.Selected].HandleMouse(rect, ev)
+		return m.Menus[m.Selected].HandleMouse(m.menuRect, ev)
 	}
 	return false
 }
 
-func (m *MenuBar) HandleKey(ev *tcell.EventKey) bool {
+// IsExpanded returns true if the Selected menu is shown.
+func (m *MenuBar) IsExpanded() bool {
+	return m.expanded
+}
+
+// expand shows the Selected menu from its first item. It collapses after an
+// action.
+func (m *MenuBar) expand() {
+	m.expanded = true
+	m.Menus[m.Selected].update()
+	m.Menus[m.Selected].Selected = m.Menus[m.Selected].firstSelectable()
+	m.Menus[m.Selected].OnAction = m.collapse
+}
+
+// collapse hides the Selected menu and its submenus.
+func (m *MenuBar) collapse() {
+	m.expanded = false
+	if m.Selected < len(m.Menus) {
+		m.Menus[m.Selected].Collapse()
+	}
+}
+
+func (m *MenuBar) HandleKey(ev *tcell.EventKey) (handled bool) {
+	defer func() {
+		if handled {
+			m.damage.add() // An expanded menu can cover anything, and actions change anything
+		}
+	}()
 	if m.focused && len(m.Menus) > 0 {
 		if m.expanded {
 			if m.Menus[m.Selected].HandleKey(ev) {
@@ -66,20 +95,41 @@ func (m *MenuBar) HandleKey(ev *tcell.EventKey) bool {
 		switch ev.Key() {
 		case tcell.KeyLeft:
 			// Reset menu selection idx before changing
-			m.Menus[m.Selected].Selected = 0
+			m.Menus[m.Selected].Collapse()
+			m.Menus[m.Selected].Selected = m.Menus[m.Selected].firstSelectable()
 			m.Selected--
 			if m.Selected < 0 {
 				m.Selected = len(m.Menus) - 1
 			}
+			if m.expanded {
+				m.expand()
+			}
 		case tcell.KeyRight:
 			// Reset menu selection before changing
-			m.Menus[m.Selected].Selected = 0
+			m.Menus[m.Selected].Collapse()
+			m.Menus[m.Selected].Selected = m.Menus[m.Selected].firstSelectable()
 			m.Selected++
 			if m.Selected >= len(m.Menus) {
 				m.Selected = 0
 			}
+			if m.expanded {
+				m.expand()
+			}
 		case tcell.KeyEnter:
-			m.expanded = !m.expanded
+			if m.expanded {
+				m.collapse()
+			} else {
+				m.expand()
+			}
+		case tcell.KeyEscape:
+			if !m.expanded {
+				return false
+			}
+			m.collapse()
+		case tcell.KeyRune:
+			if m.expanded || !m.openMnemonic(ev) {
+				return false
+			}
 		default:
 			return false
 		}
@@ -88,12 +138,59 @@ func (m *MenuBar) HandleKey(ev *tcell.EventKey) bool {
 	return false
 }
 
+// HandleShortcut opens the menu whose mnemonic is typed with Alt, or activates
+// the action of any menu whose Shortcut matches the key event, whether or not
+// the MenuBar is focused. Returns true if the event was handled. The MenuBar is
+// focused after opening a menu.
+func (m *MenuBar) HandleShortcut(ev *tcell.EventKey) (handled bool) {
+	defer func() {
+		if handled {
+			m.damage.add()
+		}
+	}()
+	if ev.Modifiers()&tcell.ModAlt != 0 && m.openMnemonic(ev) {
+		return true
+	}
+	for i := range m.Menus {
+		if m.Menus[i].HandleShortcut(ev) {
+			if m.expanded {
+				m.collapse()
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// openMnemonic focuses and expands the menu whose mnemonic is the rune typed.
+// Returns false if there is none.
+func (m *MenuBar) openMnemonic(ev *tcell.EventKey) bool {
+	for i := range m.Menus {
+		if matchesMnemonic(MnemonicOf(m.Menus[i].Title), ev) {
+			m.collapse()
+			m.focused = true
+			m.Selected = i
+			m.expand()
+			return true
+		}
+	}
+	return false
+}
+
+// TakeDamage reports the whole screen after the MenuBar handled a key.
+func (m *MenuBar) TakeDamage() ([]Rect, bool) {
+	return m.damage.take()
+}
+
 func (m *MenuBar) SetFocused(b bool) {
 	m.focused = b
+	if len(m.Menus) == 0 {
+		return
+	}
 	if !b {
-		m.expanded = false
+		m.collapse()
 	}
-	m.Menus[m.Selected].Selected = 0
+	m.Menus[m.Selected].Selected = m.Menus[m.Selected].firstSelectable()
 	// NOTE: I am not calling SetFocused on the highlighted menu because currently
 	// menus do not accept focus.
 }
@@ -114,11 +211,16 @@ func (m *MenuBar) Draw(rect Rect, s tcell.Screen) {
 				style = m.SelectionStyle
 
 				if m.expanded { // If the selected menu is also expanded
-					menuW, menuH := m.Menus[i].DisplaySize(0, 0)
-					m.Menus[i].Draw(Rect{r.X, r.Y + 1, menuW, menuH}, s)
+					// The menu is as high as the screen below the MenuBar allows
+					screenW, screenH := s.Size()
+					menuW, menuH := m.Menus[i].DisplaySize(screenW-r.X, screenH-r.Y-1)
+					m.menuRect = Rect{r.X, r.Y + 1, menuW, menuH}
+					m.Menus[i].Draw(m.menuRect, s)
 				}
 			}
-			DrawString(r.X, r.Y, fmt.Sprintf(" %s ", m.Menus[i].Title), style, s)
+			s.SetContent(r.X, r.Y, ' ', nil, style)
+			DrawMnemonicString(r.X+1, r.Y, m.Menus[i].Title, style, s)
+			s.SetContent(r.X+r.W-1, r.Y, ' ', nil, style)
 		}
 	}
 }
@@ -39,6 +39,21 @@ func (p *Padding) SetFocused(b bool) {
 	}
 }
 
+func (p *Padding) FocusRect(rect Rect) (Rect, bool) {
+	if p.Child == nil {
+		return Rect{}, false
+	}
+	return focusRectOf(p.Child, p.GetChildRect(rect))
+}
+
+// TakeDamage reports the damage of the Child.
+func (p *Padding) TakeDamage() ([]Rect, bool) {
+	if p.Child == nil {
+		return nil, false
+	}
+	return damageOf(p.Child)
+}
+
 func (p *Padding) DisplaySize(boundsW, boundsH int) (w, h int) {
 	if p.Child != nil {
 		w, h = p.Child.DisplaySize(boundsW-p.Left-p.Right, boundsH-p.Top-p.Bottom)
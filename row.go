@@ -11,6 +11,7 @@ type Row struct {
 	FocusedIndex  int // Index of child that receives focus
 	OnKeyEvent    func(row *Row, ev *tcell.EventKey) bool
 	focused       bool
+	damage        damage
 }
 
 func (r *Row) FocusNext() {
@@ -95,6 +96,7 @@ func (r *Row) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
 
 func (r *Row) HandleKey(ev *tcell.EventKey) bool {
 	if r.OnKeyEvent != nil && r.OnKeyEvent(r, ev) {
+		r.damage.add() // The callback could change anything
 		return true
 	}
 	for i := range r.Children {
@@ -112,6 +114,27 @@ func (r *Row) SetFocused(b bool) {
 	}
 }
 
+// FocusRect returns the focused part of the focused child, or the rect of the
+// child if it is not a FocusReporter.
+func (r *Row) FocusRect(rect Rect) (Rect, bool) {
+	if !r.focused || r.FocusedIndex >= len(r.Children) {
+		return Rect{}, false
+	}
+	childRect := r.GetChildRects(rect)[r.FocusedIndex]
+	if focus, ok := focusRectOf(r.Children[r.FocusedIndex], childRect); ok {
+		return focus, true
+	}
+	return childRect, true
+}
+
+// TakeDamage reports the damage of every child.
+func (r *Row) TakeDamage() ([]Rect, bool) {
+	for _, child := range r.Children {
+		r.damage.addWidget(child)
+	}
+	return r.damage.take()
+}
+
 func (r *Row) DisplaySize(boundsW, boundsH int) (w, h int) {
 	rects := r.GetChildRects(Rect{0, 0, boundsW, boundsH})
 	if rects == nil {
@@ -6,7 +6,99 @@ type Scaffold struct {
 	MenuBar    *MenuBar
 	MainWidget Widget
 	Floating   []Widget
-	focusIdx   int
+	StatusBar  *StatusBar // Drawn on the bottom row, below the MainWidget
+	// ModalDimStyle replaces the colors of everything beneath a modal. If it
+	// is the default style, everything beneath is drawn dim, instead.
+	ModalDimStyle tcell.Style
+	// DialogStyle is the style of dialogs like MessageBox. If it is nil, then
+	// DefaultDialogStyle is used.
+	DialogStyle *DialogStyle
+	focusIdx    int
+	modals      []modal
+	popup       *ContextMenu // Opened by PopupMenu
+	damage      damage
+}
+
+// A modal is a widget pushed onto the Scaffold with PushModal.
+type modal struct {
+	widget   Widget
+	onClose  func(result interface{})
+	focusIdx int // Focus of the Scaffold before the modal was pushed
+}
+
+// PushModal shows the widget above everything else in the Scaffold, and gives
+// it all input until it is closed with PopModal or CancelModal. The widget is
+// drawn with the Scaffold's whole rect, so use an Align or a Center to position
+// it. When the modal closes, onClose is called with its result, and the focus
+// returns to what had it before. Modals can be pushed on top of other modals.
+func (s *Scaffold) PushModal(widget Widget, onClose func(result interface{})) {
+	if len(s.modals) > 0 {
+		s.modals[len(s.modals)-1].widget.SetFocused(false)
+	} else {
+		s.setFocusMenuBar(false)
+		s.setFocusMainWidget(false)
+		s.setFocusFloating(false)
+	}
+	s.modals = append(s.modals, modal{widget, onClose, s.focusIdx})
+	widget.SetFocused(true)
+}
+
+// PopModal closes the top modal, and calls its onClose with the result.
+func (s *Scaffold) PopModal(result interface{}) {
+	if len(s.modals) == 0 {
+		return
+	}
+	top := s.modals[len(s.modals)-1]
+	s.modals[len(s.modals)-1] = modal{}
+	s.modals = s.modals[:len(s.modals)-1]
+	top.widget.SetFocused(false)
+
+	if len(s.modals) > 0 {
+		s.modals[len(s.modals)-1].widget.SetFocused(true)
+	} else {
+		switch top.focusIdx {
+		case 0:
+			s.FocusMenuBar()
+		case 1:
+			s.FocusMainWidget()
+		case 2:
+			s.FocusFloating()
+		}
+	}
+	if top.onClose != nil {
+		top.onClose(result)
+	}
+}
+
+// CancelModal closes the top modal with a nil result. This happens when Escape
+// is pressed and the modal does not handle it.
+func (s *Scaffold) CancelModal() {
+	s.PopModal(nil)
+}
+
+// HasModal returns true if a modal is open.
+func (s *Scaffold) HasModal() bool {
+	return len(s.modals) > 0
+}
+
+// PopupMenu opens the menu at the screen position, above everything but
+// modals, like a context menu when a widget is right-clicked. It closes like a
+// ContextMenu, or when another popup menu is opened.
+func (s *Scaffold) PopupMenu(menu *Menu, x, y int) {
+	s.ClosePopupMenu()
+	if s.MenuBar != nil && s.MenuBar.IsExpanded() {
+		s.MenuBar.collapse()
+	}
+	s.popup = &ContextMenu{Menu: menu}
+	s.popup.Open(x, y)
+}
+
+// ClosePopupMenu closes the menu opened with PopupMenu, if it is open.
+func (s *Scaffold) ClosePopupMenu() {
+	if s.popup != nil {
+		s.popup.Close()
+		s.popup = nil
+	}
 }
 
 func (s *Scaffold) IsMenuBarFocused() bool {
@@ -67,11 +159,41 @@ func (s *Scaffold) mainWidgetRect(currentRect Rect) Rect {
 		rect.Y += h
 		rect.H -= h
 	}
+	if s.StatusBar != nil {
+		_, h := s.StatusBar.DisplaySize(rect.W, rect.H)
+		rect.H -= h
+	}
 	w, h := s.MainWidget.DisplaySize(rect.W, rect.H)
 	return Rect{rect.X, rect.Y, w, h}
 }
 
+// statusBarRect returns the rect of the StatusBar on the bottom row.
+func (s *Scaffold) statusBarRect(currentRect Rect) Rect {
+	w, h := s.StatusBar.DisplaySize(currentRect.W, currentRect.H)
+	return Rect{currentRect.X, currentRect.Y + currentRect.H - h, w, h}
+}
+
 func (s *Scaffold) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
+	if len(s.modals) > 0 {
+		_ = s.modals[len(s.modals)-1].widget.HandleMouse(currentRect, ev)
+		return true // Nothing beneath a modal receives events
+	}
+	if s.popup != nil && s.popup.HandleMouse(currentRect, ev) {
+		if !s.popup.IsOpen() {
+			s.popup = nil
+		}
+		return true
+	}
+	// An expanded menu is drawn over everything but floating widgets
+	if s.MenuBar != nil && s.MenuBar.IsExpanded() {
+		sizeX, sizeY := s.MenuBar.DisplaySize(currentRect.W, currentRect.H)
+		if s.MenuBar.HandleMouse(Rect{currentRect.X, currentRect.Y, sizeX, sizeY}, ev) {
+			s.setFocusMainWidget(false)
+			s.setFocusFloating(false)
+			s.focusIdx = 0
+			return true
+		}
+	}
 	if len(s.Floating) > 0 {
 		for i := len(s.Floating) - 1; i >= 0; i-- {
 			if s.Floating[i].HandleMouse(currentRect, ev) {
@@ -92,6 +214,9 @@ func (s *Scaffold) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
 			}
 		}
 	}
+	if s.StatusBar != nil && s.StatusBar.HandleMouse(s.statusBarRect(currentRect), ev) {
+		return true
+	}
 	if s.MainWidget != nil {
 		if s.MainWidget.HandleMouse(s.mainWidgetRect(currentRect), ev) {
 			s.setFocusMenuBar(false)
@@ -113,27 +238,98 @@ func (s *Scaffold) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
 }
 
 func (s *Scaffold) HandleKey(ev *tcell.EventKey) bool {
-	if len(s.Floating) > 0 {
-		for i := len(s.Floating) - 1; i >= 0; i-- {
-			if s.Floating[i].HandleKey(ev) {
+	if len(s.modals) > 0 {
+		if !s.modals[len(s.modals)-1].widget.HandleKey(ev) && ev.Key() == tcell.KeyEscape {
+			s.CancelModal()
+			s.damage.add()
+		}
+		return true
+	}
+	if s.popup != nil {
+		s.popup.HandleKey(ev)
+		if !s.popup.IsOpen() {
+			s.popup = nil
+		}
+		s.damage.add() // The popup is drawn over anything
+		return true
+	}
+	// Shortcuts of the menus and the StatusBar come before the focused widget
+	if s.MenuBar != nil && s.MenuBar.HandleShortcut(ev) {
+		if s.MenuBar.IsExpanded() && s.focusIdx != 0 {
+			s.setFocusMainWidget(false)
+			s.setFocusFloating(false)
+			s.focusIdx = 0
+			s.damage.add()
+		}
+		return true
+	}
+	if s.StatusBar != nil && s.StatusBar.HandleKey(ev) {
+		return true
+	}
+	if s.MenuBar != nil {
+		if s.focusIdx == 0 {
+			if s.MenuBar.HandleKey(ev) {
 				return true
 			}
-		}
-	} else {
-		if s.MainWidget != nil {
-			if s.MainWidget.HandleKey(ev) {
+			switch ev.Key() {
+			case tcell.KeyF10, tcell.KeyEscape:
+				s.leaveMenuBar()
+				s.damage.add()
 				return true
 			}
+			return false
 		}
-		if s.MenuBar != nil {
-			return s.MenuBar.HandleKey(ev)
+		if ev.Key() == tcell.KeyF10 {
+			s.FocusMenuBar()
+			s.damage.add()
+			return true
 		}
 	}
+	if len(s.Floating) > 0 {
+		for i := len(s.Floating) - 1; i >= 0; i-- {
+			if s.Floating[i].HandleKey(ev) {
+				return true
+			}
+		}
+	} else if s.MainWidget != nil {
+		return s.MainWidget.HandleKey(ev)
+	}
 	return false
 }
 
-func (s *Scaffold) SetFocused(b bool) {
+// leaveMenuBar gives the focus back to the floating widgets or the MainWidget,
+// after F10 or Escape are pressed in the MenuBar.
+func (s *Scaffold) leaveMenuBar() {
 	if len(s.Floating) > 0 {
+		s.FocusFloating()
+	} else if s.MainWidget != nil {
+		s.FocusMainWidget()
+	}
+}
+
+// TakeDamage reports the damage of every child, or the whole screen after the
+// focus moved between them, or a modal or popup menu closed.
+func (s *Scaffold) TakeDamage() ([]Rect, bool) {
+	s.damage.addWidget(s.MainWidget)
+	for _, w := range s.Floating {
+		s.damage.addWidget(w)
+	}
+	for _, m := range s.modals {
+		s.damage.addWidget(m.widget)
+	}
+	if s.MenuBar != nil {
+		s.damage.addWidget(s.MenuBar)
+	}
+	if s.StatusBar != nil {
+		s.damage.addWidget(s.StatusBar)
+	}
+	return s.damage.take()
+}
+
+func (s *Scaffold) SetFocused(b bool) {
+	if len(s.modals) > 0 {
+		s.modals[len(s.modals)-1].widget.SetFocused(b)
+	} else if len(s.Floating) > 0 {
 		s.Floating[len(s.Floating)-1].SetFocused(b)
 		s.focusIdx = 2
 	} else if s.MainWidget != nil {
@@ -153,10 +349,39 @@ func (s *Scaffold) Draw(rect Rect, screen tcell.Screen) {
 	if s.MainWidget != nil {
 		s.MainWidget.Draw(s.mainWidgetRect(rect), screen)
 	}
+	if s.StatusBar != nil {
+		s.StatusBar.Draw(s.statusBarRect(rect), screen)
+	}
 	if s.MenuBar != nil {
 		s.MenuBar.Draw(Rect{0, 0, rect.W, 1}, screen)
 	}
 	for i := 0; i < len(s.Floating); i++ { // Draw back to front
 		s.Floating[i].Draw(rect, screen)
 	}
+	if s.popup != nil {
+		s.popup.Draw(rect, screen)
+	}
+	for i := range s.modals {
+		s.dim(rect, screen)
+		s.modals[i].widget.Draw(rect, screen)
+	}
+}
+
+// dim restyles the cells of the rect to show they are beneath a modal.
+func (s *Scaffold) dim(rect Rect, screen tcell.Screen) {
+	fg, bg, _ := s.ModalDimStyle.Decompose()
+	for y := rect.Y; y < rect.Y+rect.H; y++ {
+		for x := rect.X; x < rect.X+rect.W; x++ {
+			mainc, combc, style, width := screen.GetContent(x, y)
+			if s.ModalDimStyle == tcell.StyleDefault {
+				style = style.Dim(true)
+			} else {
+				style = style.Foreground(fg).Background(bg)
+			}
+			screen.SetContent(x, y, mainc, combc, style)
+			if width == 2 {
+				x++ // Skip the second half of a wide rune
+			}
+		}
+	}
 }
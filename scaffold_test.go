@@ -0,0 +1,82 @@
+package dos
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestScaffoldModalFocus(t *testing.T) {
+	main, floating := &Button{Text: "Main"}, &Button{Text: "Floating"}
+	menuBar := &MenuBar{Menus: []MenuBarItem{{Title: "&File"}}}
+	escape := tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone)
+
+	tests := []struct {
+		name    string
+		focus   func(s *Scaffold)
+		focused func(s *Scaffold) bool
+	}{
+		{"main widget", (*Scaffold).FocusMainWidget, func(s *Scaffold) bool {
+			return s.IsMainWidgetFocused() && main.focused && !menuBar.focused && !floating.focused
+		}},
+		{"menu bar", (*Scaffold).FocusMenuBar, func(s *Scaffold) bool {
+			return s.IsMenuBarFocused() && menuBar.focused && !main.focused && !floating.focused
+		}},
+		{"floating", (*Scaffold).FocusFloating, func(s *Scaffold) bool {
+			return s.IsFloatingFocused() && floating.focused && !main.focused && !menuBar.focused
+		}},
+	}
+	for _, test := range tests {
+		s := &Scaffold{MenuBar: menuBar, MainWidget: main, Floating: []Widget{floating}}
+		test.focus(s)
+
+		first, second := &Button{Text: "First"}, &Button{Text: "Second"}
+		var results []interface{}
+		onClose := func(result interface{}) { results = append(results, result) }
+		s.PushModal(first, onClose)
+		if !first.focused || main.focused || menuBar.focused || floating.focused {
+			t.Errorf("%s: focus was not given to the modal", test.name)
+		}
+		s.PushModal(second, onClose)
+		if first.focused || !second.focused {
+			t.Errorf("%s: focus was not given to the second modal", test.name)
+		}
+
+		s.PopModal("done")
+		if !first.focused || second.focused || test.focused(s) {
+			t.Errorf("%s: focus did not return to the first modal", test.name)
+		}
+		s.HandleKey(escape) // Not handled by the Button
+		if first.focused || !test.focused(s) || s.HasModal() {
+			t.Errorf("%s: focus was not restored after closing every modal", test.name)
+		}
+		if len(results) != 2 || results[0] != "done" || results[1] != nil {
+			t.Errorf("%s: modals closed with %v", test.name, results)
+		}
+		s.PopModal(nil) // Nothing to close
+		if len(results) != 2 || !test.focused(s) {
+			t.Errorf("%s: popping without a modal changed something", test.name)
+		}
+		floating.SetFocused(false)
+		main.SetFocused(false)
+		menuBar.SetFocused(false)
+	}
+}
+
+func TestScaffoldModalOnClose(t *testing.T) {
+	main := &Button{Text: "Main"}
+	s := &Scaffold{MainWidget: main}
+	s.FocusMainWidget()
+	next := &Button{Text: "Next"}
+	s.PushModal(&Button{}, func(interface{}) {
+		s.PushModal(next, nil) // A modal opening another as it closes
+	})
+	s.CancelModal()
+	if !next.focused || main.focused || len(s.modals) != 1 {
+		t.Error("the modal pushed by onClose does not have the focus")
+	}
+	s.CancelModal()
+	if !main.focused || s.HasModal() {
+		t.Error("focus did not return to the main widget")
+	}
+}
@@ -0,0 +1,351 @@
+package dos
+
+import "github.com/gdamore/tcell/v2"
+
+// scrollUnbounded is the bound passed to DisplaySize of the Child of a
+// ScrollView on the axes it scrolls.
+const scrollUnbounded = 1 << 16
+
+// A ScrollView shows part of a Child that may be larger than it. On the axes
+// where Horizontal or Vertical is true, the Child is as large as its
+// DisplaySize with unbounded space, so do not use a Child that fills its
+// bounds on those axes, like a Center. A scrollbar is drawn on the right or
+// bottom side when the Child does not fit, which can be clicked and dragged.
+// The mouse wheel and, when the Child does not handle them, the arrow keys,
+// PgUp, PgDn, Home and End scroll, too.
+//
+// After the Child handles a key, the ScrollView scrolls to keep its focused
+// part visible, if the Child is a FocusReporter.
+type ScrollView struct {
+	Child          Widget
+	Horizontal     bool // Whether to scroll horizontally
+	Vertical       bool // Whether to scroll vertically
+	ScrollX        int  // Columns of the Child hidden on the left
+	ScrollY        int  // Rows of the Child hidden on the top
+	ScrollbarStyle tcell.Style
+	OnScroll       func(x, y int)
+
+	focused     bool
+	followFocus bool         // Scroll to the focus when drawn next
+	pressed     bool         // The primary mouse button is down
+	dragging    int          // Scrollbar of the thumb being dragged: 'h', 'v' or zero
+	dragOffset  int          // Position of the mouse on the dragged thumb
+	last        scrollLayout // Layout when last drawn, for keys
+	damage      damage
+}
+
+// scrollLayout is where the parts of a ScrollView are drawn.
+type scrollLayout struct {
+	rect       Rect // The whole ScrollView
+	view       Rect // Visible part of the Child
+	content    Rect // Rect of the whole Child
+	vbar, hbar bool // Whether the scrollbars are shown
+	maxX, maxY int  // Greatest ScrollX and ScrollY
+}
+
+// layout returns where the parts of the ScrollView are drawn in the rect, and
+// keeps ScrollX and ScrollY inside the Child.
+func (v *ScrollView) layout(rect Rect) scrollLayout {
+	var l scrollLayout
+	l.rect = rect
+	l.view = rect
+	if v.Child == nil {
+		return l
+	}
+	// Scrollbars take space from the view, so may make the other necessary
+	for i := 0; i < 2; i++ {
+		w, h := v.childSize(l.view)
+		l.vbar = v.Vertical && h > rect.H-boolInt(l.hbar)
+		l.hbar = v.Horizontal && w > rect.W-boolInt(l.vbar)
+		l.view = Rect{rect.X, rect.Y, Max(rect.W-boolInt(l.vbar), 0), Max(rect.H-boolInt(l.hbar), 0)}
+	}
+	w, h := v.childSize(l.view)
+	l.maxX, l.maxY = Max(w-l.view.W, 0), Max(h-l.view.H, 0)
+	v.ScrollX = Clamp(v.ScrollX, 0, l.maxX)
+	v.ScrollY = Clamp(v.ScrollY, 0, l.maxY)
+	l.content = Rect{l.view.X - v.ScrollX, l.view.Y - v.ScrollY, w, h}
+	return l
+}
+
+// childSize returns the size of the Child in the view. The Child is never
+// smaller than the view.
+func (v *ScrollView) childSize(view Rect) (w, h int) {
+	boundsW, boundsH := view.W, view.H
+	if v.Horizontal {
+		boundsW = scrollUnbounded
+	}
+	if v.Vertical {
+		boundsH = scrollUnbounded
+	}
+	w, h = v.Child.DisplaySize(boundsW, boundsH)
+	if !v.Horizontal {
+		w = view.W
+	}
+	if !v.Vertical {
+		h = view.H
+	}
+	return Max(w, view.W), Max(h, view.H)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ScrollTo scrolls so the column x and row y of the Child are in the top-left
+// corner, as far as the Child allows when next drawn.
+func (v *ScrollView) ScrollTo(x, y int) {
+	if x == v.ScrollX && y == v.ScrollY {
+		return
+	}
+	v.ScrollX, v.ScrollY = Max(x, 0), Max(y, 0)
+	v.damage.add(v.last.rect)
+	if v.OnScroll != nil {
+		v.OnScroll(v.ScrollX, v.ScrollY)
+		v.damage.add() // The callback could change anything
+	}
+}
+
+// scrollBy scrolls by columns and rows, inside the Child.
+func (v *ScrollView) scrollBy(l scrollLayout, dx, dy int) {
+	v.ScrollTo(Clamp(v.ScrollX+dx, 0, l.maxX), Clamp(v.ScrollY+dy, 0, l.maxY))
+}
+
+// scrollToFocus scrolls so the focused part of the Child is visible.
+func (v *ScrollView) scrollToFocus(l scrollLayout) {
+	focus, ok := focusRectOf(v.Child, l.content)
+	if !ok {
+		return
+	}
+	dx, dy := 0, 0
+	if focus.X+focus.W > l.view.X+l.view.W {
+		dx = focus.X + focus.W - (l.view.X + l.view.W)
+	}
+	if focus.X-dx < l.view.X { // Show the start of a focus wider than the view
+		dx = focus.X - l.view.X
+	}
+	if focus.Y+focus.H > l.view.Y+l.view.H {
+		dy = focus.Y + focus.H - (l.view.Y + l.view.H)
+	}
+	if focus.Y-dy < l.view.Y {
+		dy = focus.Y - l.view.Y
+	}
+	v.scrollBy(l, dx, dy)
+}
+
+// thumb returns the position and length of the thumb in a track of the length,
+// for a scroll of the maximum, showing a part of the content.
+func thumb(track, scroll, max, view, content int) (pos, length int) {
+	if track < 1 {
+		return 0, 0 // Only the arrows fit
+	}
+	length = Clamp(track*view/Max(content, 1), 1, track)
+	if max > 0 {
+		pos = (track - length) * scroll / max
+	}
+	return pos, length
+}
+
+// scrollForThumb returns the least scroll that draws the thumb at the position.
+func scrollForThumb(pos, track, length, max int) int {
+	free := track - length
+	if free <= 0 {
+		return 0
+	}
+	return (Clamp(pos, 0, free)*max + free - 1) / free // Rounded up, as thumb rounds down
+}
+
+func (v *ScrollView) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
+	l := v.layout(currentRect)
+	posX, posY := ev.Position()
+	buttons := ev.Buttons()
+	pressed := buttons&tcell.ButtonPrimary != 0
+	defer func() { v.pressed = pressed }()
+
+	if v.dragging != 0 {
+		if !pressed {
+			v.dragging = 0
+		} else if v.dragging == 'v' {
+			_, length := thumb(l.view.H-2, v.ScrollY, l.maxY, l.view.H, l.content.H)
+			v.ScrollTo(v.ScrollX, scrollForThumb(posY-l.view.Y-1-v.dragOffset, l.view.H-2, length, l.maxY))
+		} else {
+			_, length := thumb(l.view.W-2, v.ScrollX, l.maxX, l.view.W, l.content.W)
+			v.ScrollTo(scrollForThumb(posX-l.view.X-1-v.dragOffset, l.view.W-2, length, l.maxX), v.ScrollY)
+		}
+		return true
+	}
+	if !currentRect.HasPoint(posX, posY) {
+		return false
+	}
+
+	switch {
+	case buttons&tcell.WheelUp != 0:
+		v.scrollBy(l, 0, -3)
+		return true
+	case buttons&tcell.WheelDown != 0:
+		v.scrollBy(l, 0, 3)
+		return true
+	case buttons&tcell.WheelLeft != 0:
+		v.scrollBy(l, -3, 0)
+		return true
+	case buttons&tcell.WheelRight != 0:
+		v.scrollBy(l, 3, 0)
+		return true
+	}
+
+	if l.vbar && posX == l.view.X+l.view.W && posY < l.view.Y+l.view.H {
+		if pressed && !v.pressed {
+			v.pressScrollbar(l, 'v', posY-l.view.Y)
+		}
+		return true
+	}
+	if l.hbar && posY == l.view.Y+l.view.H && posX < l.view.X+l.view.W {
+		if pressed && !v.pressed {
+			v.pressScrollbar(l, 'h', posX-l.view.X)
+		}
+		return true
+	}
+	if l.view.HasPoint(posX, posY) && v.Child != nil && v.Child.HandleMouse(l.content, ev) {
+		v.SetFocused(true)
+		return true
+	}
+	return false
+}
+
+// pressScrollbar handles a click at the position along the scrollbar: the
+// arrows scroll a line, the track a page, and the thumb starts dragging.
+func (v *ScrollView) pressScrollbar(l scrollLayout, bar int, pos int) {
+	view, content, scroll, max := l.view.H, l.content.H, v.ScrollY, l.maxY
+	if bar == 'h' {
+		view, content, scroll, max = l.view.W, l.content.W, v.ScrollX, l.maxX
+	}
+	thumbPos, length := thumb(view-2, scroll, max, view, content)
+	delta := 0
+	switch {
+	case pos == 0:
+		delta = -1
+	case pos == view-1:
+		delta = 1
+	case pos-1 < thumbPos:
+		delta = -view
+	case pos-1 >= thumbPos+length:
+		delta = view
+	default:
+		v.dragging = bar
+		v.dragOffset = pos - 1 - thumbPos
+	}
+	if bar == 'h' {
+		v.scrollBy(l, delta, 0)
+	} else {
+		v.scrollBy(l, 0, delta)
+	}
+}
+
+func (v *ScrollView) HandleKey(ev *tcell.EventKey) bool {
+	if v.Child != nil && v.Child.HandleKey(ev) {
+		v.followFocus = true
+		v.damage.add(v.last.rect) // Following the focus may scroll
+		return true
+	}
+	if !v.focused {
+		return false
+	}
+	page := Max(v.last.view.H-1, 1)
+	switch ev.Key() {
+	case tcell.KeyUp:
+		v.scrollBy(v.last, 0, -1)
+	case tcell.KeyDown:
+		v.scrollBy(v.last, 0, 1)
+	case tcell.KeyLeft:
+		v.scrollBy(v.last, -1, 0)
+	case tcell.KeyRight:
+		v.scrollBy(v.last, 1, 0)
+	case tcell.KeyPgUp:
+		v.scrollBy(v.last, 0, -page)
+	case tcell.KeyPgDn:
+		v.scrollBy(v.last, 0, page)
+	case tcell.KeyHome:
+		v.ScrollTo(v.ScrollX, 0)
+	case tcell.KeyEnd:
+		v.ScrollTo(v.ScrollX, v.last.maxY)
+	default:
+		return false
+	}
+	return true
+}
+
+// TakeDamage reports the damage of the Child, and the whole ScrollView if it
+// scrolled.
+func (v *ScrollView) TakeDamage() ([]Rect, bool) {
+	v.damage.addWidget(v.Child)
+	return v.damage.take()
+}
+
+func (v *ScrollView) SetFocused(b bool) {
+	v.focused = b
+	if v.Child != nil {
+		v.Child.SetFocused(b)
+	}
+}
+
+// FocusRect returns the focused part of the Child, inside the visible part.
+func (v *ScrollView) FocusRect(rect Rect) (Rect, bool) {
+	if v.Child == nil {
+		return Rect{}, false
+	}
+	l := v.layout(rect)
+	if focus, ok := focusRectOf(v.Child, l.content); ok {
+		return intersect(focus, l.view), true
+	}
+	return Rect{}, false
+}
+
+func (v *ScrollView) DisplaySize(boundsW, boundsH int) (w, h int) {
+	return boundsW, boundsH
+}
+
+func (v *ScrollView) Draw(rect Rect, s tcell.Screen) {
+	l := v.layout(rect)
+	if v.followFocus {
+		v.followFocus = false
+		v.scrollToFocus(l)
+		l = v.layout(rect)
+	}
+	v.last = l
+	if v.Child != nil {
+		v.Child.Draw(l.content, NewClipScreen(s, l.view))
+	}
+
+	if l.vbar {
+		drawScrollbar(l.view.X+l.view.W, l.view.Y, 0, 1, l.view.H, v.ScrollY, l.maxY, l.content.H, '▲', '▼', v.ScrollbarStyle, s)
+	}
+	if l.hbar {
+		drawScrollbar(l.view.X, l.view.Y+l.view.H, 1, 0, l.view.W, v.ScrollX, l.maxX, l.content.W, '◄', '►', v.ScrollbarStyle, s)
+	}
+	if l.vbar && l.hbar { // Corner between them
+		s.SetContent(l.view.X+l.view.W, l.view.Y+l.view.H, ' ', nil, v.ScrollbarStyle)
+	}
+}
+
+// drawScrollbar draws a scrollbar of the length from the cell in the
+// direction dx, dy, with its thumb for the scroll of the maximum, showing the
+// length of the content.
+func drawScrollbar(x, y, dx, dy, length, scroll, max, content int, start, end rune, style tcell.Style, s tcell.Screen) {
+	if length < 1 {
+		return
+	}
+	s.SetContent(x, y, start, nil, style)
+	if length > 1 {
+		s.SetContent(x+dx*(length-1), y+dy*(length-1), end, nil, style)
+	}
+	thumbPos, thumbLength := thumb(length-2, scroll, max, length, content)
+	for i := 0; i < length-2; i++ {
+		r := '▒'
+		if i >= thumbPos && i < thumbPos+thumbLength {
+			r = '█'
+		}
+		s.SetContent(x+dx*(i+1), y+dy*(i+1), r, nil, style)
+	}
+}
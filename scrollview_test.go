@@ -0,0 +1,63 @@
+package dos
+
+import "testing"
+
+func TestThumb(t *testing.T) {
+	tests := []struct {
+		track, scroll, max, view, content int
+		pos, length                       int
+	}{
+		{10, 0, 0, 10, 10, 0, 10},      // Everything is visible
+		{10, 0, 0, 10, 0, 0, 10},       // No content
+		{10, 0, 990, 10, 1000, 0, 1},   // Shortest thumb, at the start
+		{10, 495, 990, 10, 1000, 4, 1}, // Halfway
+		{10, 990, 990, 10, 1000, 9, 1}, // At the end
+		{10, 0, 5, 5, 10, 0, 5},
+		{10, 5, 5, 5, 10, 5, 5},
+		{1, 3, 3, 2, 5, 0, 1},
+		{0, 5, 10, 2, 12, 0, 0},  // No track between the arrows
+		{-1, 5, 10, 1, 11, 0, 0}, // Not even room for both arrows
+	}
+	for _, test := range tests {
+		pos, length := thumb(test.track, test.scroll, test.max, test.view, test.content)
+		if pos != test.pos || length != test.length {
+			t.Errorf("thumb(%d, %d, %d, %d, %d) is %d, %d, expected %d, %d", test.track, test.scroll, test.max,
+				test.view, test.content, pos, length, test.pos, test.length)
+		}
+	}
+}
+
+func TestScrollForThumb(t *testing.T) {
+	tests := []struct {
+		pos, track, length, max int
+		scroll                  int
+	}{
+		{0, 10, 1, 990, 0},
+		{4, 10, 1, 990, 440},
+		{9, 10, 1, 990, 990},
+		{20, 10, 1, 990, 990}, // Dragged past the end
+		{-3, 10, 1, 990, 0},   // Dragged past the start
+		{3, 10, 10, 50, 0},    // The thumb fills the track
+		{0, 0, 0, 10, 0},
+	}
+	for _, test := range tests {
+		if scroll := scrollForThumb(test.pos, test.track, test.length, test.max); scroll != test.scroll {
+			t.Errorf("scrollForThumb(%d, %d, %d, %d) is %d, expected %d", test.pos, test.track, test.length,
+				test.max, scroll, test.scroll)
+		}
+	}
+
+	// Dragging the thumb to a position scrolls to where it is drawn there, when
+	// the content scrolls by more positions than the track has
+	for _, size := range [][3]int{{10, 10, 1000}, {10, 5, 12}, {3, 4, 100}, {20, 20, 21}} {
+		track, view, content := size[0], size[1], size[2]
+		max := content - view
+		_, length := thumb(track, 0, max, view, content)
+		for pos := 0; pos <= track-length; pos++ {
+			scroll := scrollForThumb(pos, track, length, max)
+			if got, _ := thumb(track, scroll, max, view, content); got != pos {
+				t.Errorf("thumb dragged to %d in %v is drawn at %d", pos, size, got)
+			}
+		}
+	}
+}
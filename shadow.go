@@ -30,6 +30,21 @@ func (s *Shadow) SetFocused(b bool) {
 	}
 }
 
+func (s *Shadow) FocusRect(rect Rect) (Rect, bool) {
+	if s.Child == nil {
+		return Rect{}, false
+	}
+	return focusRectOf(s.Child, rect)
+}
+
+// TakeDamage reports the damage of the Child.
+func (s *Shadow) TakeDamage() ([]Rect, bool) {
+	if s.Child == nil {
+		return nil, false
+	}
+	return damageOf(s.Child)
+}
+
 func (s *Shadow) DisplaySize(boundsW, boundsH int) (w, h int) {
 	if s.Child != nil {
 		return s.Child.DisplaySize(boundsW, boundsH)
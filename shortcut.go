@@ -0,0 +1,112 @@
+package dos
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// ParseMnemonic removes the '&' that marks the mnemonic of a title like
+// "&File", and returns the title as drawn with the index of the rune that was
+// marked, or -1 if there is none. A "&&" is drawn as a single '&'.
+func ParseMnemonic(title string) (text string, mnemonic int) {
+	if strings.IndexByte(title, '&') < 0 {
+		return title, -1 // Common case
+	}
+	var b strings.Builder
+	mnemonic = -1
+	runes := []rune(title)
+	count := 0 // Runes written
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '&' && i+1 < len(runes) {
+			i++
+			if runes[i] != '&' && mnemonic < 0 {
+				mnemonic = count
+			}
+		}
+		b.WriteRune(runes[i])
+		count++
+	}
+	return b.String(), mnemonic
+}
+
+// MnemonicOf returns the lowercase mnemonic of the title, or zero if it has
+// none.
+func MnemonicOf(title string) rune {
+	text, mnemonic := ParseMnemonic(title)
+	if mnemonic < 0 {
+		return 0
+	}
+	return unicode.ToLower([]rune(text)[mnemonic])
+}
+
+// mnemonicWidth returns the width of the title as it is drawn.
+func mnemonicWidth(title string) int {
+	text, _ := ParseMnemonic(title)
+	return runewidth.StringWidth(text)
+}
+
+// DrawMnemonicString prints the title like DrawString, without the '&' that
+// marks its mnemonic, and underlines the mnemonic.
+func DrawMnemonicString(x, y int, title string, style tcell.Style, screen tcell.Screen) {
+	text, mnemonic := ParseMnemonic(title)
+	var col, byteIdx, runeIdx int
+	for byteIdx < len(text) {
+		r, size := utf8.DecodeRuneInString(text[byteIdx:])
+		runeStyle := style
+		if runeIdx == mnemonic {
+			runeStyle = style.Underline(true)
+		}
+		screen.SetContent(x+col, y, r, nil, runeStyle)
+		byteIdx += size
+		runeIdx++
+		col += runewidth.RuneWidth(r)
+	}
+}
+
+// matchesMnemonic returns true if the key event is the rune of the mnemonic.
+func matchesMnemonic(mnemonic rune, ev *tcell.EventKey) bool {
+	return mnemonic != 0 && ev.Key() == tcell.KeyRune && unicode.ToLower(ev.Rune()) == mnemonic
+}
+
+// MatchesShortcut returns true if the key event is the key combination written
+// in the shortcut, like "Ctrl+S", "Alt+X", "F5" or "Shift+Delete". Modifiers
+// are joined to the key with '+', and keys are named as in tcell.KeyNames, or
+// are a single rune. Case is ignored.
+func MatchesShortcut(shortcut string, ev *tcell.EventKey) bool {
+	parts := strings.Split(shortcut, "+")
+	key := parts[len(parts)-1]
+	var mods tcell.ModMask
+	for _, mod := range parts[:len(parts)-1] {
+		switch strings.ToLower(mod) {
+		case "shift":
+			mods |= tcell.ModShift
+		case "ctrl":
+			mods |= tcell.ModCtrl
+		case "alt":
+			mods |= tcell.ModAlt
+		case "meta":
+			mods |= tcell.ModMeta
+		default:
+			return false
+		}
+	}
+
+	evMods := ev.Modifiers()
+	name, ok := tcell.KeyNames[ev.Key()]
+	switch {
+	case ev.Key() == tcell.KeyRune:
+		name = string(ev.Rune())
+		evMods &^= tcell.ModShift // Shift is part of the rune
+		mods &^= tcell.ModShift
+	case strings.HasPrefix(name, "Ctrl-"):
+		name = name[len("Ctrl-"):]
+		evMods |= tcell.ModCtrl // Not always reported for control characters
+	case !ok:
+		return false
+	}
+	return key != "" && evMods == mods && strings.EqualFold(name, key)
+}
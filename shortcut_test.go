@@ -0,0 +1,76 @@
+package dos
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestParseMnemonic(t *testing.T) {
+	tests := []struct {
+		title    string
+		text     string
+		mnemonic int
+		of       rune
+	}{
+		{"&File", "File", 0, 'f'},
+		{"E&xit", "Exit", 1, 'x'},
+		{"No mnemonic", "No mnemonic", -1, 0},
+		{"Save && Quit", "Save & Quit", -1, 0},
+		{"&&Save &As", "&Save As", 6, 'a'},
+		{"&&&x", "&x", 1, 'x'},
+		{"&A &B", "A B", 0, 'a'}, // Only the first is the mnemonic
+		{"Trailing&", "Trailing&", -1, 0},
+		{"&", "&", -1, 0},
+		{"世&界", "世界", 1, '界'}, // Index of the rune, not the byte
+	}
+	for _, test := range tests {
+		text, mnemonic := ParseMnemonic(test.title)
+		if text != test.text || mnemonic != test.mnemonic {
+			t.Errorf("ParseMnemonic(%q) is %q, %d, expected %q, %d", test.title, text, mnemonic, test.text, test.mnemonic)
+		}
+		if of := MnemonicOf(test.title); of != test.of {
+			t.Errorf("MnemonicOf(%q) is %q, expected %q", test.title, of, test.of)
+		}
+	}
+}
+
+func TestMatchesShortcut(t *testing.T) {
+	tests := []struct {
+		shortcut string
+		key      tcell.Key
+		r        rune
+		mods     tcell.ModMask
+		matches  bool
+	}{
+		{"Ctrl+S", tcell.KeyCtrlS, 0, tcell.ModCtrl, true},
+		{"Ctrl+S", tcell.KeyCtrlS, 0, tcell.ModNone, true}, // Ctrl is not always reported
+		{"ctrl+s", tcell.KeyCtrlS, 0, tcell.ModCtrl, true},
+		{"Ctrl+S", tcell.KeyRune, 's', tcell.ModCtrl, true},
+		{"Ctrl+S", tcell.KeyRune, 's', tcell.ModNone, false},
+		{"Ctrl+S", tcell.KeyCtrlA, 0, tcell.ModCtrl, false},
+		{"Shift+Delete", tcell.KeyDelete, 0, tcell.ModShift, true},
+		{"Shift+Delete", tcell.KeyDelete, 0, tcell.ModNone, false},
+		{"Delete", tcell.KeyDelete, 0, tcell.ModShift, false},
+		{"F5", tcell.KeyF5, 0, tcell.ModNone, true},
+		{"f5", tcell.KeyF5, 0, tcell.ModNone, true},
+		{"F5", tcell.KeyF6, 0, tcell.ModNone, false},
+		{"F5", tcell.KeyF5, 0, tcell.ModAlt, false},
+		{"Enter", tcell.KeyEnter, 0, tcell.ModNone, true},
+		{"Alt+X", tcell.KeyRune, 'x', tcell.ModAlt, true},
+		{"Alt+X", tcell.KeyRune, 'X', tcell.ModAlt | tcell.ModShift, true}, // Shift is part of the rune
+		{"Alt+X", tcell.KeyRune, 'x', tcell.ModNone, false},
+		{"x", tcell.KeyRune, 'X', tcell.ModShift, true},
+		{"?", tcell.KeyRune, '?', tcell.ModShift, true},
+		{"x", tcell.KeyRune, 'y', tcell.ModNone, false},
+		{"Hyper+X", tcell.KeyRune, 'x', tcell.ModNone, false}, // Unknown modifier
+		{"Ctrl+", tcell.KeyRune, '+', tcell.ModCtrl, false},
+		{"", tcell.KeyRune, 'x', tcell.ModNone, false},
+	}
+	for _, test := range tests {
+		ev := tcell.NewEventKey(test.key, test.r, test.mods)
+		if matches := MatchesShortcut(test.shortcut, ev); matches != test.matches {
+			t.Errorf("MatchesShortcut(%q) for %s is %v", test.shortcut, ev.Name(), matches)
+		}
+	}
+}
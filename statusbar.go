@@ -0,0 +1,128 @@
+package dos
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// A StatusItem is a segment of a StatusBar. An item with a Key, like "F1" or
+// "Alt+X", is a hint for the key, which calls the Action when pressed, as
+// does clicking the item. An item without a Key or Action is only text, which
+// can be changed at any time to show information, like "Ln 12, Col 4".
+type StatusItem struct {
+	Key    string // See MatchesShortcut for how the key is written.
+	Text   string
+	Action func()
+	Right  bool // Whether the item is on the right side of the StatusBar
+}
+
+// width returns the number of columns the item takes.
+func (item *StatusItem) width() int {
+	w := runewidth.StringWidth(item.Key) + runewidth.StringWidth(item.Text)
+	if item.Key != "" && item.Text != "" {
+		w++ // Space between them
+	}
+	return w
+}
+
+// A StatusBar is a line of StatusItems, like "F1 Help  Alt+X Exit" at the
+// bottom of the Scaffold. The keys of its items work whether or not the
+// StatusBar is focused, so it never takes the focus.
+type StatusBar struct {
+	Items       []StatusItem
+	NormalStyle tcell.Style
+	KeyStyle    tcell.Style // If KeyStyle is the default style, then NormalStyle is drawn bold.
+	damage      damage
+}
+
+// ItemRects returns a slice of Rects of each item. The returned slice length
+// will be equal to the length of Items. Items that do not fit, after the items
+// before them on their side, have an empty Rect.
+func (b *StatusBar) ItemRects(rect Rect) []Rect {
+	rects := make([]Rect, len(b.Items))
+	left, right := rect.X+1, rect.X+rect.W-1
+	for i := range b.Items {
+		if b.Items[i].Right {
+			continue
+		}
+		w := b.Items[i].width()
+		if left+w > right {
+			break
+		}
+		rects[i] = Rect{left, rect.Y, w, 1}
+		left += w + 2
+	}
+	for i := len(b.Items) - 1; i >= 0; i-- { // Right items are laid out from the right edge
+		if !b.Items[i].Right {
+			continue
+		}
+		w := b.Items[i].width()
+		if right-w < left { // Would cover the left items
+			break
+		}
+		right -= w
+		rects[i] = Rect{right, rect.Y, w, 1}
+		right -= 2
+	}
+	return rects
+}
+
+func (b *StatusBar) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
+	if ev.Buttons()&tcell.ButtonPrimary == 0 {
+		return false
+	}
+	for i, r := range b.ItemRects(currentRect) {
+		if r.W > 0 && r.HasPoint(ev.Position()) && b.Items[i].Action != nil {
+			b.Items[i].Action()
+			return true
+		}
+	}
+	return false
+}
+
+// HandleKey calls the Action of the item whose Key matches the event.
+func (b *StatusBar) HandleKey(ev *tcell.EventKey) bool {
+	for i := range b.Items {
+		if b.Items[i].Key != "" && b.Items[i].Action != nil && MatchesShortcut(b.Items[i].Key, ev) {
+			b.Items[i].Action()
+			b.damage.add() // The action could change anything
+			return true
+		}
+	}
+	return false
+}
+
+// TakeDamage reports the whole screen after the StatusBar called an Action for
+// a key.
+func (b *StatusBar) TakeDamage() ([]Rect, bool) {
+	return b.damage.take()
+}
+
+func (b *StatusBar) SetFocused(bool) {}
+
+func (b *StatusBar) DisplaySize(boundsW, boundsH int) (w, h int) {
+	return boundsW, Min(1, boundsH)
+}
+
+func (b *StatusBar) Draw(rect Rect, s tcell.Screen) {
+	if rect.H < 1 {
+		return
+	}
+	DrawRect(Rect{rect.X, rect.Y, rect.W, 1}, ' ', b.NormalStyle, s)
+	keyStyle := b.KeyStyle
+	if keyStyle == tcell.StyleDefault {
+		keyStyle = b.NormalStyle.Bold(true)
+	}
+	for i, r := range b.ItemRects(rect) {
+		if r.W == 0 {
+			continue // Does not fit
+		}
+		item := &b.Items[i]
+		col := r.X
+		if item.Key != "" {
+			DrawString(col, r.Y, item.Key, keyStyle, s)
+			col += runewidth.StringWidth(item.Key) + 1
+		}
+		DrawString(col, r.Y, item.Text, b.NormalStyle, s)
+	}
+}
@@ -0,0 +1,571 @@
+package dos
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// A TableModel provides the cells of a Table by their row and column, so a
+// Table can show rows from anywhere, like a database cursor.
+type TableModel interface {
+	Rows() int
+	Cell(row, col int) string
+}
+
+// A TableSorter is a TableModel that sorts its own rows, like with a database
+// query. Otherwise, the Table sorts the text of the cells.
+type TableSorter interface {
+	TableModel
+	// Sort sorts the rows by the column, and returns the new index of the row
+	// that was at the index cursor, so the Table keeps the same row selected.
+	Sort(col int, descending bool, cursor int) int
+}
+
+// A TableEditor is a TableModel whose cells can be changed by the user, in the
+// columns that are Editable.
+type TableEditor interface {
+	TableModel
+	SetCell(row, col int, text string)
+}
+
+type ColumnWidthType uint8
+
+const (
+	ColumnFixed   ColumnWidthType = iota // Width is in cells
+	ColumnPercent                        // Width is a percentage of the space left by the other columns
+	ColumnAuto                           // As wide as the widest cell of the first rows
+)
+
+// autoFitRows is the number of rows measured for a ColumnAuto.
+const autoFitRows = 1000
+
+// A TableColumn is the header and layout of a column of a Table. The width of
+// a ColumnAuto is measured again only when the Table is refreshed or the number
+// of rows changes.
+type TableColumn struct {
+	Title     string
+	Width     int
+	WidthType ColumnWidthType
+	Align     Alignment
+	Editable  bool // Whether the cells can be edited, if the model is a TableEditor
+}
+
+// A Table shows the rows of its Model in Columns, below a header. Clicking
+// the title of a column sorts the rows by it, and clicking it again reverses
+// the order. Dragging the line on the right of a title resizes the column,
+// which makes its width fixed.
+//
+// One row is selected, and moved with the arrow keys, PgUp, PgDn, Home and
+// End, or clicking. Enter or double-clicking a row activates it, unless the
+// cell at the cursor is editable, which opens a TextInput to edit it, like F2.
+// Enter saves the edit and Escape cancels it.
+type Table struct {
+	Model          TableModel
+	Columns        []TableColumn
+	NormalStyle    tcell.Style
+	HeaderStyle    tcell.Style
+	CursorStyle    tcell.Style // Style of the selected row when focused
+	SelectedStyle  tcell.Style // If SelectedStyle is the default style, then NormalStyle is reversed.
+	ScrollbarStyle tcell.Style
+	OnSelect       func(row int) // Called with the row of the Model when the cursor moves to it
+	OnActivate     func(row int)
+
+	row, col       int   // Cursor, in sorted rows
+	scroll         int   // Index of the first visible row
+	height         int   // Rows visible when last drawn
+	rect           Rect  // Where the Table was last drawn
+	order          []int // Rows of the Model in sorted order, when the Table sorts them
+	autoWidths     []int // Measured width of each ColumnAuto
+	autoRows       int   // Rows of the Model when autoWidths were measured
+	sortCol        int
+	sortDescending bool
+	sorted         bool
+	resizing       bool // A border of the header is being dragged
+	resizeCol      int  // Column on the left of the dragged border
+	pressed        bool
+	lastClick      time.Time
+	lastClicked    int
+	editor         *TextInput // Not nil while editing the cell at the cursor
+	focused        bool
+	damage         damage
+}
+
+// Rows returns the number of rows of the Model, or zero if there is none.
+func (t *Table) Rows() int {
+	if t.Model == nil {
+		return 0
+	}
+	return t.Model.Rows()
+}
+
+// ModelRow returns the row of the Model shown at the index of the sorted rows.
+func (t *Table) ModelRow(i int) int {
+	if t.order != nil && i < len(t.order) {
+		return t.order[i]
+	}
+	return i
+}
+
+// Cursor returns the row of the Model that is selected.
+func (t *Table) Cursor() int {
+	return t.ModelRow(t.row)
+}
+
+// SetCursor selects the row at the index of the sorted rows.
+func (t *Table) SetCursor(i int) {
+	if t.Rows() == 0 {
+		return
+	}
+	i = Clamp(i, 0, t.Rows()-1)
+	t.showRow(i)
+	if i != t.row {
+		t.row = i
+		if t.OnSelect != nil {
+			t.OnSelect(t.ModelRow(i))
+			t.damage.add() // The callback could change anything
+		}
+	}
+}
+
+// showRow scrolls so the row at the index of the sorted rows is visible.
+func (t *Table) showRow(i int) {
+	if i < t.scroll {
+		t.scroll = i
+	} else if t.height > 0 && i >= t.scroll+t.height {
+		t.scroll = i - t.height + 1
+	}
+}
+
+// SortBy sorts the rows by the column.
+func (t *Table) SortBy(col int, descending bool) {
+	t.sortCol, t.sortDescending, t.sorted = col, descending, true
+	t.Refresh()
+}
+
+// Sorting returns the column the rows are sorted by, or false if they are not.
+func (t *Table) Sorting() (col int, descending bool, ok bool) {
+	return t.sortCol, t.sortDescending, t.sorted
+}
+
+// Refresh sorts the rows again and measures the ColumnAuto widths, after the
+// Model changed. The cursor stays on the same row of the Model.
+func (t *Table) Refresh() {
+	cursor := t.Cursor()
+	t.order = nil
+	t.autoWidths = nil
+	if !t.sorted || t.Model == nil {
+		return
+	}
+	defer func() { t.showRow(t.row) }()
+	if sorter, ok := t.Model.(TableSorter); ok {
+		t.row = sorter.Sort(t.sortCol, t.sortDescending, t.row)
+		return
+	}
+	t.order = make([]int, t.Model.Rows())
+	for i := range t.order {
+		t.order[i] = i
+	}
+	sort.SliceStable(t.order, func(i, j int) bool {
+		a, b := t.Model.Cell(t.order[i], t.sortCol), t.Model.Cell(t.order[j], t.sortCol)
+		if t.sortDescending {
+			return lessCell(b, a)
+		}
+		return lessCell(a, b)
+	})
+	for i, row := range t.order {
+		if row == cursor {
+			t.row = i
+			break
+		}
+	}
+}
+
+// lessCell compares cells as numbers if both are numbers, or else as text
+// ignoring case.
+func lessCell(a, b string) bool {
+	x, errA := strconv.ParseFloat(strings.TrimSpace(a), 64)
+	y, errB := strconv.ParseFloat(strings.TrimSpace(b), 64)
+	if errA == nil && errB == nil {
+		return x < y
+	}
+	return strings.ToLower(a) < strings.ToLower(b)
+}
+
+// measureAutoWidths measures the title and the cells of the first rows of
+// each ColumnAuto, unless they were measured for the same number of rows.
+func (t *Table) measureAutoWidths() {
+	if len(t.autoWidths) == len(t.Columns) && t.autoRows == t.Rows() {
+		return
+	}
+	t.autoWidths = make([]int, len(t.Columns))
+	t.autoRows = t.Rows()
+	for i := range t.Columns {
+		if t.Columns[i].WidthType != ColumnAuto {
+			continue
+		}
+		w := runewidth.StringWidth(t.Columns[i].Title) + 1 // Room for the sort arrow
+		for row := 0; row < Min(t.autoRows, autoFitRows); row++ {
+			w = Max(w, runewidth.StringWidth(t.Model.Cell(row, i)))
+		}
+		t.autoWidths[i] = w
+	}
+}
+
+// columnWidths returns the width of each column in a Table of the width,
+// without the lines between columns.
+func (t *Table) columnWidths(width int) []int {
+	t.measureAutoWidths()
+	widths := make([]int, len(t.Columns))
+	left := width - Max(len(t.Columns)-1, 0) // Lines between the columns
+	for i := range t.Columns {
+		switch t.Columns[i].WidthType {
+		case ColumnFixed:
+			widths[i] = t.Columns[i].Width
+		case ColumnAuto:
+			widths[i] = t.autoWidths[i]
+		}
+		left -= widths[i]
+	}
+	for i := range t.Columns {
+		if t.Columns[i].WidthType == ColumnPercent {
+			widths[i] = Max(left, 0) * t.Columns[i].Width / 100
+		}
+	}
+	for i := range widths {
+		widths[i] = Max(widths[i], 1)
+	}
+	return widths
+}
+
+// columnRects returns the rect of each column drawn in the rect, including the
+// header.
+func (t *Table) columnRects(rect Rect) []Rect {
+	widths := t.columnWidths(rect.W)
+	rects := make([]Rect, len(widths))
+	x := rect.X
+	for i, w := range widths {
+		rects[i] = Rect{x, rect.Y, w, rect.H}
+		x += w + 1
+	}
+	return rects
+}
+
+// bodyRows returns the number of rows visible below the header.
+func bodyRows(rect Rect) int {
+	return Max(rect.H-1, 0)
+}
+
+// bodyRect returns the part of the rect left of the scrollbar, which is shown
+// when the rows do not fit.
+func (t *Table) bodyRect(rect Rect) Rect {
+	if rows := bodyRows(rect); t.Rows() > rows && rows > 0 && rect.W > 1 {
+		rect.W--
+	}
+	return rect
+}
+
+// isEditable returns true if the cell of the column can be edited.
+func (t *Table) isEditable(col int) bool {
+	_, ok := t.Model.(TableEditor)
+	return ok && col >= 0 && col < len(t.Columns) && t.Columns[col].Editable
+}
+
+// Edit opens a TextInput to edit the cell at the cursor, if it is editable.
+// Returns false if it is not.
+func (t *Table) Edit() bool {
+	if t.Rows() == 0 || !t.isEditable(t.col) {
+		return false
+	}
+	text := t.Model.Cell(t.ModelRow(t.row), t.col)
+	t.editor = &TextInput{
+		Text:         text,
+		NormalStyle:  t.NormalStyle,
+		FocusedStyle: t.CursorStyle,
+	}
+	t.editor.SetCursorPos(len([]rune(text)))
+	t.editor.SetFocused(true)
+	return true
+}
+
+// finishEdit closes the TextInput, saving the text to the Model if save is
+// true.
+func (t *Table) finishEdit(save bool) {
+	if t.editor == nil {
+		return
+	}
+	if save {
+		t.Model.(TableEditor).SetCell(t.ModelRow(t.row), t.col, t.editor.Text)
+		if t.sorted && t.col == t.sortCol {
+			t.Refresh()
+		} else {
+			t.autoWidths = nil // The cell may be wider
+		}
+	}
+	t.editor = nil
+}
+
+// activate edits the cell at the cursor, or activates its row.
+func (t *Table) activate() {
+	if t.Edit() || t.Rows() == 0 {
+		return
+	}
+	if t.OnActivate != nil {
+		t.OnActivate(t.ModelRow(t.row))
+		t.damage.add()
+	}
+}
+
+func (t *Table) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
+	posX, posY := ev.Position()
+	pressed := ev.Buttons()&tcell.ButtonPrimary != 0
+	wasPressed := t.pressed
+	t.pressed = pressed
+	body := t.bodyRect(currentRect)
+	rects := t.columnRects(body)
+
+	if t.resizing && t.resizeCol < len(rects) {
+		if pressed {
+			t.Columns[t.resizeCol].WidthType = ColumnFixed
+			t.Columns[t.resizeCol].Width = Max(posX-rects[t.resizeCol].X, 1)
+		} else {
+			t.resizing = false
+		}
+		return true
+	}
+	if !currentRect.HasPoint(posX, posY) {
+		return false
+	}
+	rows := bodyRows(currentRect)
+	maxScroll := Max(t.Rows()-rows, 0)
+	switch {
+	case ev.Buttons()&tcell.WheelUp != 0:
+		t.scroll = Clamp(t.scroll-3, 0, maxScroll)
+	case ev.Buttons()&tcell.WheelDown != 0:
+		t.scroll = Clamp(t.scroll+3, 0, maxScroll)
+	case pressed && !wasPressed:
+		if t.editor != nil {
+			t.finishEdit(false)
+		}
+		if posX >= body.X+body.W { // Scrollbar
+			if row := posY - currentRect.Y - 1; row >= 0 {
+				t.pressScrollbar(row, rows, maxScroll)
+			}
+			break
+		}
+		if posY == currentRect.Y { // Header
+			for i, r := range rects {
+				if posX == r.X+r.W {
+					t.resizing, t.resizeCol = true, i
+					break
+				}
+				if posX >= r.X && posX < r.X+r.W {
+					col, descending, ok := t.Sorting()
+					t.SortBy(i, ok && col == i && !descending) // Clicking again reverses
+					break
+				}
+			}
+			break
+		}
+		i := t.scroll + posY - currentRect.Y - 1
+		if i >= t.Rows() {
+			break
+		}
+		for col, r := range rects {
+			if posX >= r.X && posX < r.X+r.W {
+				t.col = col
+			}
+		}
+		t.SetCursor(i)
+		if i == t.lastClicked && ev.When().Sub(t.lastClick) <= doubleClickTime {
+			t.lastClick = time.Time{}
+			t.activate()
+			break
+		}
+		t.lastClick, t.lastClicked = ev.When(), i
+	default: // Moves, drags and releases do nothing
+		return false
+	}
+	t.SetFocused(true)
+	return true
+}
+
+// pressScrollbar handles a click on the row of the scrollbar: the arrows scroll
+// a row, and the track a page.
+func (t *Table) pressScrollbar(row, rows, maxScroll int) {
+	thumbPos, length := thumb(rows-2, t.scroll, maxScroll, rows, t.Rows())
+	switch {
+	case row == 0:
+		t.scroll--
+	case row == rows-1:
+		t.scroll++
+	case row-1 < thumbPos:
+		t.scroll -= rows
+	case row-1 >= thumbPos+length:
+		t.scroll += rows
+	}
+	t.scroll = Clamp(t.scroll, 0, maxScroll)
+}
+
+func (t *Table) HandleKey(ev *tcell.EventKey) bool {
+	if !t.focused {
+		return false
+	}
+	if t.editor != nil {
+		switch ev.Key() {
+		case tcell.KeyEnter:
+			t.finishEdit(true)
+		case tcell.KeyEscape:
+			t.finishEdit(false)
+		default:
+			_ = t.editor.HandleKey(ev)
+		}
+		t.damage.add(t.rect)
+		return true // Keys do not leave the cell while editing
+	}
+	page := Max(t.height-1, 1)
+	switch ev.Key() {
+	case tcell.KeyUp:
+		t.SetCursor(t.row - 1)
+	case tcell.KeyDown:
+		t.SetCursor(t.row + 1)
+	case tcell.KeyPgUp:
+		t.SetCursor(t.row - page)
+	case tcell.KeyPgDn:
+		t.SetCursor(t.row + page)
+	case tcell.KeyHome:
+		t.SetCursor(0)
+	case tcell.KeyEnd:
+		t.SetCursor(t.Rows() - 1)
+	case tcell.KeyLeft:
+		t.col = Max(t.col-1, 0)
+	case tcell.KeyRight:
+		t.col = Clamp(t.col+1, 0, Max(len(t.Columns)-1, 0))
+	case tcell.KeyEnter:
+		t.activate()
+	case tcell.KeyF2:
+		if !t.Edit() {
+			return false
+		}
+	default:
+		return false
+	}
+	t.damage.add(t.rect)
+	return true
+}
+
+// TakeDamage reports the Table after it handled a key, or the whole screen if
+// OnSelect or OnActivate were called.
+func (t *Table) TakeDamage() ([]Rect, bool) {
+	return t.damage.take()
+}
+
+func (t *Table) SetFocused(b bool) {
+	t.focused = b
+	if !b {
+		t.finishEdit(false)
+	}
+}
+
+// FocusRect returns the selected row if the Table is focused.
+func (t *Table) FocusRect(rect Rect) (Rect, bool) {
+	row := t.row - t.scroll
+	if !t.focused || row < 0 || row >= bodyRows(rect) {
+		return Rect{}, false
+	}
+	return Rect{rect.X, rect.Y + 1 + row, rect.W, 1}, true
+}
+
+func (t *Table) DisplaySize(boundsW, boundsH int) (w, h int) {
+	return boundsW, boundsH
+}
+
+// drawCell draws the text aligned in the rect, truncated if it does not fit.
+func drawCell(text string, align Alignment, rect Rect, style tcell.Style, s tcell.Screen) {
+	text = runewidth.Truncate(text, rect.W, "…")
+	x := rect.X
+	switch w := runewidth.StringWidth(text); align {
+	case AlignRight:
+		x += rect.W - w
+	case AlignCenter:
+		x += (rect.W - w) / 2
+	}
+	DrawString(x, rect.Y, text, style, s)
+}
+
+func (t *Table) Draw(rect Rect, s tcell.Screen) {
+	t.rect = rect
+	if rect.W < 1 || rect.H < 1 {
+		return
+	}
+	n := t.Rows()
+	if t.order != nil && len(t.order) != n {
+		t.Refresh() // Rows were added or removed
+	}
+	rows := bodyRows(rect)
+	t.height = rows
+	t.row = Clamp(t.row, 0, Max(n-1, 0))
+	t.scroll = Clamp(t.scroll, 0, Max(n-rows, 0))
+
+	body := t.bodyRect(rect)
+	bodyW := body.W
+	if bodyW < rect.W {
+		drawScrollbar(rect.X+bodyW, rect.Y+1, 0, 1, rows, t.scroll, n-rows, n, '▲', '▼', t.ScrollbarStyle, s)
+	}
+	s = NewClipScreen(s, body)
+	selectedStyle := t.SelectedStyle
+	if selectedStyle == tcell.StyleDefault {
+		selectedStyle = t.NormalStyle.Reverse(true)
+	}
+
+	// Header
+	DrawRect(Rect{rect.X, rect.Y, bodyW, 1}, ' ', t.HeaderStyle, s)
+	rects := t.columnRects(body)
+	for i, r := range rects {
+		title := t.Columns[i].Title
+		titleW := r.W
+		if col, descending, ok := t.Sorting(); ok && col == i {
+			arrow := '▲'
+			if descending {
+				arrow = '▼'
+			}
+			titleW--
+			s.SetContent(r.X+r.W-1, r.Y, arrow, nil, t.HeaderStyle)
+		}
+		drawCell(title, t.Columns[i].Align, Rect{r.X, r.Y, titleW, 1}, t.HeaderStyle, s)
+		s.SetContent(r.X+r.W, r.Y, DefaultBoxDecoration.Vert, nil, t.HeaderStyle)
+	}
+
+	// Rows
+	DrawRect(Rect{rect.X, rect.Y + 1, bodyW, rows}, ' ', t.NormalStyle, s)
+	for row := 0; row < rows && t.scroll+row < n; row++ {
+		i := t.scroll + row
+		y := rect.Y + 1 + row
+		style := t.NormalStyle
+		if i == t.row && t.focused {
+			style = t.CursorStyle
+		} else if i == t.row {
+			style = selectedStyle
+		}
+		DrawRect(Rect{rect.X, y, bodyW, 1}, ' ', style, s)
+		modelRow := t.ModelRow(i)
+		for col, r := range rects {
+			cellRect := Rect{r.X, y, r.W, 1}
+			cellStyle := style
+			if i == t.row && col == t.col && t.focused && t.isEditable(col) {
+				cellStyle = style.Reverse(true) // Show which cell is edited
+			}
+			if i == t.row && col == t.col && t.editor != nil {
+				t.editor.Draw(cellRect, NewClipScreen(s, cellRect))
+			} else {
+				DrawRect(cellRect, ' ', cellStyle, s)
+				drawCell(t.Model.Cell(modelRow, col), t.Columns[col].Align, cellRect, cellStyle, s)
+			}
+			s.SetContent(r.X+r.W, y, DefaultBoxDecoration.Vert, nil, style)
+		}
+	}
+}
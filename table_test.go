@@ -0,0 +1,137 @@
+package dos
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// A testTable is a TableModel of rows of cells.
+type testTable [][]string
+
+func (m testTable) Rows() int {
+	return len(m)
+}
+
+func (m testTable) Cell(row, col int) string {
+	return m[row][col]
+}
+
+func TestLessCell(t *testing.T) {
+	tests := []struct {
+		a, b string
+		less bool
+	}{
+		{"2", "10", true},
+		{"10", "2", false},
+		{" 3 ", "4", true},
+		{"1.5", "-2", false},
+		{"-2", "1e3", true},
+		{"apple", "Banana", true},
+		{"b", "A", false},
+		{"10", "9 lives", true}, // Not both numbers
+		{"same", "SAME", false},
+		{"", "0", true},
+	}
+	for _, test := range tests {
+		if less := lessCell(test.a, test.b); less != test.less {
+			t.Errorf("lessCell(%q, %q) is %v", test.a, test.b, less)
+		}
+	}
+}
+
+func TestTableColumnWidths(t *testing.T) {
+	model := testTable{
+		{"a", "hello world", "1"},
+		{"b", "hi", "22"},
+	}
+	tests := []struct {
+		columns []TableColumn
+		width   int
+		want    []int
+	}{
+		{[]TableColumn{
+			{Width: 5},
+			{Title: "Name", WidthType: ColumnAuto},
+			{Width: 50, WidthType: ColumnPercent},
+			{Width: 50, WidthType: ColumnPercent},
+		}, 40, []int{5, 11, 10, 10}},
+		{[]TableColumn{
+			{Title: "Wide title", WidthType: ColumnAuto}, // Wider than its cells
+			{Width: 100, WidthType: ColumnPercent},
+		}, 30, []int{11, 18}},
+		{[]TableColumn{
+			{Width: 30},
+			{Width: 25, WidthType: ColumnPercent}, // No space is left
+			{WidthType: ColumnAuto},
+		}, 20, []int{30, 1, 2}},
+		{[]TableColumn{
+			{Width: 0}, // Too narrow
+			{Width: 33, WidthType: ColumnPercent},
+		}, 10, []int{1, 2}},
+	}
+	for i, test := range tests {
+		table := &Table{Model: model, Columns: test.columns}
+		if got := table.columnWidths(test.width); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("columns %d: widths are %v, expected %v", i, got, test.want)
+		}
+	}
+}
+
+func TestTableSort(t *testing.T) {
+	model := testTable{
+		{"carol", "30"},
+		{"alice", "9"},
+		{"bob", "30"},
+		{"dave", "100"},
+	}
+	table := &Table{Model: model, Columns: []TableColumn{{Title: "Name"}, {Title: "Age"}}}
+	table.SetCursor(2) // bob
+
+	tests := []struct {
+		col        int
+		descending bool
+		order      []int // Rows of the Model
+		row        int   // Index of bob in the sorted rows
+	}{
+		{0, false, []int{1, 2, 0, 3}, 1},
+		{0, true, []int{3, 0, 2, 1}, 2},
+		{1, false, []int{1, 0, 2, 3}, 2}, // Equal ages keep the order of the Model
+		{1, true, []int{3, 0, 2, 1}, 2},
+	}
+	for _, test := range tests {
+		table.SortBy(test.col, test.descending)
+		var order []int
+		for i := 0; i < table.Rows(); i++ {
+			order = append(order, table.ModelRow(i))
+		}
+		if !reflect.DeepEqual(order, test.order) {
+			t.Errorf("sorted by %d, %v: order is %v, expected %v", test.col, test.descending, order, test.order)
+		}
+		if table.Cursor() != 2 || table.row != test.row {
+			t.Errorf("sorted by %d, %v: cursor is on %d at %d, expected 2 at %d", test.col, test.descending, table.Cursor(), table.row, test.row)
+		}
+	}
+}
+
+func TestTableMouseFocus(t *testing.T) {
+	rect := Rect{0, 0, 20, 5}
+	table := &Table{Model: testTable{{"a"}, {"b"}}, Columns: []TableColumn{{Width: 100, WidthType: ColumnPercent}}}
+	if table.HandleMouse(rect, tcell.NewEventMouse(1, 2, tcell.ButtonNone, tcell.ModNone)) || table.focused {
+		t.Error("moving the mouse over the Table handled it")
+	}
+	if !table.HandleMouse(rect, tcell.NewEventMouse(1, 2, tcell.ButtonPrimary, tcell.ModNone)) || !table.focused {
+		t.Error("a press did not focus the Table")
+	}
+	if table.Cursor() != 1 {
+		t.Errorf("cursor is %d after clicking the second row", table.Cursor())
+	}
+	table.SetFocused(false)
+	if table.HandleMouse(rect, tcell.NewEventMouse(1, 2, tcell.ButtonNone, tcell.ModNone)) || table.focused {
+		t.Error("releasing the button handled it")
+	}
+	if !table.HandleMouse(rect, tcell.NewEventMouse(1, 2, tcell.WheelUp, tcell.ModNone)) || !table.focused {
+		t.Error("the wheel did not focus the Table")
+	}
+}
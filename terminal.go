@@ -0,0 +1,222 @@
+package dos
+
+import (
+	"github.com/fivemoreminix/dos/vterm"
+	"github.com/gdamore/tcell/v2"
+)
+
+// A Terminal displays a vterm.Terminal session, like a shell, inside its rect.
+// While focused, keys are sent to the command as the escape sequences an xterm
+// would send, and mouse events are sent if the command enabled mouse reporting.
+// The pseudo-terminal is resized to fit the rect whenever it changes.
+//
+// The scrollback of the session can be viewed with the mouse wheel, when the
+// command has not enabled mouse reporting, or with Shift+PgUp and Shift+PgDn.
+// Any other key returns the view to the live screen.
+//
+// The command's output arrives on another goroutine, so to have the App redraw
+// the Terminal, set the Term's OnUpdate to the App's RequestUpdate. Only the
+// rect of the Terminal is redrawn, as it reports it with TakeDamage.
+type Terminal struct {
+	Term        *vterm.Terminal
+	CursorStyle tcell.Style // Style of the cell under the cursor while focused
+
+	focused     bool
+	rect        Rect // Rect last drawn
+	prevButtons tcell.ButtonMask
+}
+
+func (t *Terminal) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
+	posX, posY := ev.Position()
+	buttons := ev.Buttons()
+	prevButtons := t.prevButtons
+	// Keep receiving a drag that began inside, even if it leaves the rect
+	if !currentRect.HasPoint(posX, posY) && prevButtons == 0 {
+		return false
+	}
+	t.prevButtons = buttons & (tcell.Button1 | tcell.Button2 | tcell.Button3)
+	pressed := t.prevButtons&^prevButtons != 0
+	handled := pressed || prevButtons != 0 // Presses, and the drags and releases that follow
+
+	if t.Term != nil {
+		t.Term.Lock()
+		screen := t.Term.Screen()
+		mode, sgr := screen.MouseMode()
+		if mode == vterm.MouseNone || screen.ViewOffset() > 0 {
+			if buttons&tcell.WheelUp != 0 {
+				screen.ScrollView(3)
+				handled = true
+			} else if buttons&tcell.WheelDown != 0 {
+				screen.ScrollView(-3)
+				handled = true
+			}
+			mode = vterm.MouseNone // Do not report events while viewing history
+		}
+		t.Term.Unlock()
+		x := Clamp(posX-currentRect.X, 0, currentRect.W-1)
+		y := Clamp(posY-currentRect.Y, 0, currentRect.H-1)
+		if seq := vterm.MouseSequence(ev, x, y, prevButtons, mode, sgr); seq != nil {
+			_, _ = t.Term.Write(seq)
+			handled = true
+		}
+	}
+	if pressed {
+		t.SetFocused(true)
+	}
+	return handled
+}
+
+func (t *Terminal) HandleKey(ev *tcell.EventKey) bool {
+	if !t.focused || t.Term == nil {
+		return false
+	}
+	t.Term.Lock()
+	screen := t.Term.Screen()
+	if ev.Modifiers()&tcell.ModShift != 0 && (ev.Key() == tcell.KeyPgUp || ev.Key() == tcell.KeyPgDn) {
+		_, h := screen.Size()
+		if ev.Key() == tcell.KeyPgUp {
+			screen.ScrollView(h / 2)
+		} else {
+			screen.ScrollView(-h / 2)
+		}
+		t.Term.Unlock()
+		return true
+	}
+	screen.SetViewOffset(0)
+	appCursor := screen.AppCursorKeys()
+	t.Term.Unlock()
+	if seq := vterm.KeySequence(ev, appCursor); seq != nil {
+		_, _ = t.Term.Write(seq)
+		return true
+	}
+	return false
+}
+
+func (t *Terminal) SetFocused(b bool) {
+	t.focused = b
+}
+
+// TakeDamage always reports the rect of the Terminal, as the command can change
+// the screen at any time.
+func (t *Terminal) TakeDamage() ([]Rect, bool) {
+	return []Rect{t.rect}, false
+}
+
+func (t *Terminal) DisplaySize(boundsW, boundsH int) (w, h int) {
+	return boundsW, boundsH
+}
+
+func (t *Terminal) Draw(rect Rect, s tcell.Screen) {
+	if t.Term == nil || rect.W < 1 || rect.H < 1 {
+		return
+	}
+	if rect.W != t.rect.W || rect.H != t.rect.H {
+		_ = t.Term.Resize(rect.W, rect.H)
+	}
+	t.rect = rect
+
+	t.Term.Lock()
+	defer t.Term.Unlock()
+	screen := t.Term.Screen()
+	drawScreen(rect, s, screen)
+
+	x, y, visible := screen.Cursor()
+	if !screen.AltScreen() {
+		y += screen.ViewOffset()
+	}
+	if t.focused && visible && x < rect.W && y < rect.H {
+		cell := screen.Cell(x, y)
+		style := t.CursorStyle
+		if style == tcell.StyleDefault {
+			style = cell.Style.Reverse(true)
+		}
+		s.SetContent(rect.X+x, rect.Y+y, cell.Rune, cell.Combining, style)
+	}
+}
+
+// drawScreen draws the view of the vterm.Screen at the top left of the rect.
+func drawScreen(rect Rect, s tcell.Screen, screen *vterm.Screen) {
+	w, h := screen.Size()
+	for row := 0; row < Min(h, rect.H); row++ {
+		for col := 0; col < Min(w, rect.W); col++ {
+			cell := screen.ViewCell(col, row)
+			if cell.Width == 0 {
+				continue // Second half of a double-wide rune
+			}
+			s.SetContent(rect.X+col, rect.Y+row, cell.Rune, cell.Combining, cell.Style)
+		}
+	}
+}
+
+// A ScreenView displays a vterm.Screen without a command, like output captured
+// from "git diff --color" written to the Screen. The Screen keeps its own size,
+// and lines that scrolled off of it can be viewed with the mouse wheel and with
+// PgUp and PgDn while focused.
+type ScreenView struct {
+	Screen *vterm.Screen
+
+	focused bool
+	rect    Rect // Rect last drawn
+}
+
+func (v *ScreenView) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
+	if !currentRect.HasPoint(ev.Position()) || v.Screen == nil {
+		return false
+	}
+	switch {
+	case ev.Buttons()&tcell.WheelUp != 0:
+		v.Screen.ScrollView(3)
+	case ev.Buttons()&tcell.WheelDown != 0:
+		v.Screen.ScrollView(-3)
+	case ev.Buttons()&tcell.Button1 != 0:
+		v.SetFocused(true)
+	default:
+		return false
+	}
+	return true
+}
+
+func (v *ScreenView) HandleKey(ev *tcell.EventKey) bool {
+	if !v.focused || v.Screen == nil {
+		return false
+	}
+	_, h := v.Screen.Size()
+	switch ev.Key() {
+	case tcell.KeyPgUp:
+		v.Screen.ScrollView(h / 2)
+	case tcell.KeyPgDn:
+		v.Screen.ScrollView(-h / 2)
+	case tcell.KeyHome:
+		v.Screen.SetViewOffset(v.Screen.ScrollbackLines())
+	case tcell.KeyEnd:
+		v.Screen.SetViewOffset(0)
+	default:
+		return false
+	}
+	return true
+}
+
+func (v *ScreenView) SetFocused(b bool) {
+	v.focused = b
+}
+
+// TakeDamage always reports the rect of the ScreenView, as its Screen can be
+// written to at any time.
+func (v *ScreenView) TakeDamage() ([]Rect, bool) {
+	return []Rect{v.rect}, false
+}
+
+func (v *ScreenView) DisplaySize(boundsW, boundsH int) (w, h int) {
+	if v.Screen == nil {
+		return 0, 0
+	}
+	w, h = v.Screen.Size()
+	return Min(w, boundsW), Min(h, boundsH)
+}
+
+func (v *ScreenView) Draw(rect Rect, s tcell.Screen) {
+	v.rect = rect
+	if v.Screen != nil {
+		drawScreen(rect, s, v.Screen)
+	}
+}
@@ -1,39 +1,214 @@
 package dos
 
-import "github.com/gdamore/tcell/v2"
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
 
 type TextInput struct {
 	Text             string // User-entered content.
 	Placeholder      string // Placeholder is visible when Text is empty.
 	IsHidden         bool
-	HiddenChar       rune
-	Scroll           int // Number of runes skipped when viewing.
-	Width            int // If Width is zero, then it is will be as wide as possible.
+	HiddenChar       rune // If HiddenChar is zero, then '*' is used.
+	Scroll           int  // Number of runes skipped when viewing.
+	Width            int  // If Width is zero, then it is will be as wide as possible.
 	NormalStyle      tcell.Style
 	FocusedStyle     tcell.Style
 	PlaceholderStyle tcell.Style // If PlaceholderStyle is zero (or default style), then it inherits Normal/FocusedStyle.
 	OnTextEdited     func(text string)
 
-	cursorPos int
+	cursorPos int // Index of the rune the cursor is on
 	focused   bool
+	rect      Rect // Where the TextInput was last drawn
+	damage    damage
+}
+
+// SetCursorPos moves the cursor to the rune index in Text, clamped to the end.
+func (t *TextInput) SetCursorPos(pos int) {
+	t.cursorPos = Clamp(pos, 0, len([]rune(t.Text)))
+}
+
+// CursorPos returns the index of the rune in Text that the cursor is on.
+func (t *TextInput) CursorPos() int {
+	return t.cursorPos
+}
+
+// displayRunes returns the runes as they are drawn.
+func (t *TextInput) displayRunes() []rune {
+	runes := []rune(t.Text)
+	if t.IsHidden {
+		hidden := t.HiddenChar
+		if hidden == 0 {
+			hidden = '*'
+		}
+		for i := range runes {
+			runes[i] = hidden
+		}
+	}
+	return runes
+}
+
+// scrollToCursor changes Scroll so the cursor is visible in a rect of width.
+func (t *TextInput) scrollToCursor(width int) {
+	runes := t.displayRunes()
+	t.cursorPos = Clamp(t.cursorPos, 0, len(runes))
+	t.Scroll = Clamp(t.Scroll, 0, t.cursorPos)
+	// The cursor needs one cell after the runes before it
+	for runewidth.StringWidth(string(runes[t.Scroll:t.cursorPos]))+1 > width && t.Scroll < t.cursorPos {
+		t.Scroll++
+	}
+}
+
+func (t *TextInput) edited(runes []rune) {
+	t.Text = string(runes)
+	if t.OnTextEdited != nil {
+		t.OnTextEdited(t.Text)
+		t.damage.add() // The callback could change anything
+	}
 }
 
 func (t *TextInput) HandleMouse(currentRect Rect, ev *tcell.EventMouse) bool {
-	panic("implement me")
+	if ev.Buttons()&tcell.ButtonPrimary == 0 {
+		return false
+	}
+	posX, posY := ev.Position()
+	if !currentRect.HasPoint(posX, posY) {
+		return false
+	}
+	// Place the cursor on the rune that was clicked
+	runes := t.displayRunes()
+	col := currentRect.X
+	t.cursorPos = Clamp(t.Scroll, 0, len(runes))
+	for t.cursorPos < len(runes) {
+		col += runewidth.RuneWidth(runes[t.cursorPos])
+		if col > posX {
+			break
+		}
+		t.cursorPos++
+	}
+	t.SetFocused(true)
+	return true
 }
 
 func (t *TextInput) HandleKey(ev *tcell.EventKey) bool {
-	panic("implement me")
+	if !t.focused {
+		return false
+	}
+	runes := []rune(t.Text)
+	t.cursorPos = Clamp(t.cursorPos, 0, len(runes))
+	switch ev.Key() {
+	case tcell.KeyRune:
+		if ev.Modifiers()&(tcell.ModAlt|tcell.ModCtrl) != 0 {
+			return false
+		}
+		runes = append(runes, 0)
+		copy(runes[t.cursorPos+1:], runes[t.cursorPos:])
+		runes[t.cursorPos] = ev.Rune()
+		t.cursorPos++
+		t.edited(runes)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if t.cursorPos > 0 {
+			t.cursorPos--
+			t.edited(append(runes[:t.cursorPos], runes[t.cursorPos+1:]...))
+		}
+	case tcell.KeyDelete:
+		if t.cursorPos < len(runes) {
+			t.edited(append(runes[:t.cursorPos], runes[t.cursorPos+1:]...))
+		}
+	case tcell.KeyLeft:
+		if t.cursorPos > 0 {
+			t.cursorPos--
+		}
+	case tcell.KeyRight:
+		if t.cursorPos < len(runes) {
+			t.cursorPos++
+		}
+	case tcell.KeyHome, tcell.KeyCtrlA:
+		t.cursorPos = 0
+	case tcell.KeyEnd, tcell.KeyCtrlE:
+		t.cursorPos = len(runes)
+	case tcell.KeyCtrlU: // Delete everything before the cursor
+		if t.cursorPos > 0 {
+			runes = runes[t.cursorPos:]
+			t.cursorPos = 0
+			t.edited(runes)
+		}
+	default:
+		return false
+	}
+	t.damage.add(t.rect)
+	return true
+}
+
+// TakeDamage reports the TextInput after it handled a key, or the whole screen
+// if OnTextEdited was called.
+func (t *TextInput) TakeDamage() ([]Rect, bool) {
+	return t.damage.take()
 }
 
 func (t *TextInput) SetFocused(b bool) {
 	t.focused = b
 }
 
+// FocusRect returns the cell of the cursor if the TextInput is focused.
+func (t *TextInput) FocusRect(rect Rect) (Rect, bool) {
+	if !t.focused {
+		return Rect{}, false
+	}
+	t.scrollToCursor(rect.W)
+	runes := t.displayRunes()
+	return Rect{rect.X + runewidth.StringWidth(string(runes[t.Scroll:t.cursorPos])), rect.Y, 1, 1}, true
+}
+
 func (t *TextInput) DisplaySize(boundsW, boundsH int) (w, h int) {
-	panic("implement me")
+	if t.Width > 0 {
+		return Min(t.Width, boundsW), Min(1, boundsH)
+	}
+	return boundsW, Min(1, boundsH)
 }
 
 func (t *TextInput) Draw(rect Rect, s tcell.Screen) {
-	panic("implement me")
+	t.rect = rect
+	if rect.W < 1 || rect.H < 1 {
+		return
+	}
+	style := t.NormalStyle
+	if t.focused {
+		style = t.FocusedStyle
+	}
+	DrawRect(Rect{rect.X, rect.Y, rect.W, 1}, ' ', style, s)
+
+	if t.Text == "" && t.Placeholder != "" {
+		placeholderStyle := t.PlaceholderStyle
+		if placeholderStyle == tcell.StyleDefault {
+			placeholderStyle = style
+		}
+		lines, _, _ := ConfineString(t.Placeholder, Rect{0, 0, rect.W, 1}, "\n")
+		if len(lines) > 0 {
+			DrawString(rect.X, rect.Y, lines[0], placeholderStyle, s)
+		}
+	}
+
+	t.scrollToCursor(rect.W)
+	runes := t.displayRunes()
+	col := 0
+	for i := t.Scroll; i < len(runes); i++ {
+		width := runewidth.RuneWidth(runes[i])
+		if col+width > rect.W {
+			break
+		}
+		s.SetContent(rect.X+col, rect.Y, runes[i], nil, style)
+		col += width
+	}
+
+	if t.focused {
+		cursorX := rect.X + runewidth.StringWidth(string(runes[t.Scroll:t.cursorPos]))
+		r := ' '
+		if t.cursorPos < len(runes) {
+			r = runes[t.cursorPos]
+		} else if t.Text == "" && t.Placeholder != "" {
+			r, _, _, _ = s.GetContent(cursorX, rect.Y)
+		}
+		s.SetContent(cursorX, rect.Y, r, nil, style.Reverse(true))
+	}
 }
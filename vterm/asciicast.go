@@ -0,0 +1,238 @@
+package vterm
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Types of asciicast events.
+const (
+	EventOutput = "o" // Data written by the command
+	EventInput  = "i" // Data typed by the user
+	EventResize = "r" // New size of the terminal, like "80x24"
+)
+
+// ErrBadAsciicast is returned when reading a file that is not asciicast v2.
+var ErrBadAsciicast = errors.New("vterm: not an asciicast v2 recording")
+
+// A CastHeader is the first line of an asciicast v2 recording.
+type CastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"` // Unix time the recording began
+	Duration  float64           `json:"duration,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// A CastEvent is one line of an asciicast v2 recording after the header.
+type CastEvent struct {
+	Time float64 // Seconds since the recording began
+	Type string  // EventOutput, EventInput or EventResize
+	Data string
+}
+
+// Size returns the width and height of an EventResize.
+func (e CastEvent) Size() (width, height int, ok bool) {
+	if e.Type != EventResize {
+		return 0, 0, false
+	}
+	if _, err := fmt.Sscanf(e.Data, "%dx%d", &width, &height); err != nil {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+func (e CastEvent) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return nil, err
+	}
+	typ, _ := json.Marshal(e.Type)
+	b := []byte{'['}
+	b = strconv.AppendFloat(b, e.Time, 'f', 6, 64)
+	b = append(b, ',')
+	b = append(b, typ...)
+	b = append(b, ',')
+	b = append(b, data...)
+	return append(b, ']'), nil
+}
+
+func (e *CastEvent) UnmarshalJSON(b []byte) error {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	if len(fields) != 3 {
+		return ErrBadAsciicast
+	}
+	if err := json.Unmarshal(fields[0], &e.Time); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(fields[1], &e.Type); err != nil {
+		return err
+	}
+	return json.Unmarshal(fields[2], &e.Data)
+}
+
+// A Recorder writes an asciicast v2 recording. The time of each event is the
+// time since the Recorder was made. It is safe to use from multiple goroutines.
+type Recorder struct {
+	mu      sync.Mutex
+	w       io.Writer
+	start   time.Time
+	partial []byte // Incomplete UTF-8 sequence at the end of the last output
+	err     error
+}
+
+// NewRecorder writes the header and returns a Recorder of events following it.
+// The Version of the header is set to 2, and a zero Timestamp is set to now.
+func NewRecorder(w io.Writer, header CastHeader) (*Recorder, error) {
+	start := time.Now()
+	header.Version = 2
+	if header.Timestamp == 0 {
+		header.Timestamp = start.Unix()
+	}
+	b, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(append(b, '\n')); err != nil {
+		return nil, err
+	}
+	return &Recorder{w: w, start: start}, nil
+}
+
+// Write records the bytes as output. A UTF-8 sequence split across writes is
+// held until it is complete. Returns the first error writing the recording.
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data := append(r.partial, p...)
+	// Find the start of an incomplete sequence at the end, if there is one
+	end := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				end = i
+			}
+			break
+		}
+	}
+	r.partial = append([]byte(nil), data[end:]...)
+	if end > 0 {
+		r.record(EventOutput, string(data[:end]))
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	return len(p), nil
+}
+
+// WriteInput records the bytes as input.
+func (r *Recorder) WriteInput(p []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record(EventInput, string(p))
+	return r.err
+}
+
+// Resize records a change to the size of the terminal.
+func (r *Recorder) Resize(width, height int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record(EventResize, fmt.Sprintf("%dx%d", width, height))
+	return r.err
+}
+
+func (r *Recorder) record(typ, data string) {
+	if r.err != nil {
+		return
+	}
+	b, err := json.Marshal(CastEvent{time.Since(r.start).Seconds(), typ, data})
+	if err == nil {
+		_, err = r.w.Write(append(b, '\n'))
+	}
+	r.err = err
+}
+
+// A CastReader reads the events of an asciicast v2 recording.
+type CastReader struct {
+	Header  CastHeader
+	scanner *bufio.Scanner
+}
+
+// NewCastReader reads the header of the recording.
+func NewCastReader(r io.Reader) (*CastReader, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	c := &CastReader{scanner: scanner}
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrBadAsciicast
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &c.Header); err != nil || c.Header.Version != 2 {
+		return nil, ErrBadAsciicast
+	}
+	return c, nil
+}
+
+// Next returns the next event of the recording, or io.EOF after the last.
+func (c *CastReader) Next() (CastEvent, error) {
+	for c.scanner.Scan() {
+		if len(c.scanner.Bytes()) == 0 {
+			continue
+		}
+		var e CastEvent
+		if err := json.Unmarshal(c.scanner.Bytes(), &e); err != nil {
+			return CastEvent{}, ErrBadAsciicast
+		}
+		return e, nil
+	}
+	if err := c.scanner.Err(); err != nil {
+		return CastEvent{}, err
+	}
+	return CastEvent{}, io.EOF
+}
+
+// Replay interprets every event of the recording onto the Screen at once,
+// without waiting between them. The Screen is first resized to the size in the
+// header. Input events are ignored. Returns the header of the recording.
+func Replay(r io.Reader, s *Screen) (CastHeader, error) {
+	c, err := NewCastReader(r)
+	if err != nil {
+		return CastHeader{}, err
+	}
+	s.Resize(c.Header.Width, c.Header.Height)
+	for {
+		e, err := c.Next()
+		if err == io.EOF {
+			return c.Header, nil
+		} else if err != nil {
+			return c.Header, err
+		}
+		s.play(e)
+	}
+}
+
+// play interprets the event onto the Screen.
+func (s *Screen) play(e CastEvent) {
+	switch e.Type {
+	case EventOutput:
+		_, _ = s.WriteString(e.Data)
+	case EventResize:
+		if w, h, ok := e.Size(); ok {
+			s.Resize(w, h)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package vterm
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestRecordReplay(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not installed")
+	}
+	var cast bytes.Buffer
+	term := NewTerminal(12, 4)
+	if err := term.Record(&cast, "test"); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("sh", "-c", `printf 'one\r\n\033[32mtwo \342\224\200 three four\033[m\033[1;8Hx'`)
+	if err := term.Start(cmd); err != nil {
+		t.Skipf("cannot start a pseudo-terminal: %v", err)
+	}
+	defer term.Close()
+	select {
+	case <-term.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("command did not exit")
+	}
+
+	replayed := NewScreen(1, 1)
+	header, err := Replay(bytes.NewReader(cast.Bytes()), replayed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Width != 12 || header.Height != 4 || header.Title != "test" {
+		t.Errorf("header is %+v", header)
+	}
+
+	term.Lock()
+	defer term.Unlock()
+	recorded := term.Screen()
+	if w, h := replayed.Size(); w != 12 || h != 4 {
+		t.Fatalf("replayed screen is %dx%d", w, h)
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 12; x++ {
+			a, b := recorded.Cell(x, y), replayed.Cell(x, y)
+			if a.Rune != b.Rune || a.Style != b.Style || a.Width != b.Width || a.Wrapped != b.Wrapped {
+				t.Errorf("cell %d, %d is %+v, but %+v when replayed", x, y, a, b)
+			}
+		}
+	}
+	x, y, _ := recorded.Cursor()
+	if rx, ry, _ := replayed.Cursor(); rx != x || ry != y {
+		t.Errorf("cursor is at %d, %d, but %d, %d when replayed", x, y, rx, ry)
+	}
+	expectLines(t, replayed, "one    x", "two ─ three", "four")
+}
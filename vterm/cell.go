@@ -0,0 +1,35 @@
+package vterm
+
+import "github.com/gdamore/tcell/v2"
+
+// A Cell is a single character position of the terminal grid. A double-wide
+// rune occupies two cells: the first has a Width of two, and the cell after it
+// has a Width of zero and no Rune, so it should not be drawn.
+type Cell struct {
+	Rune      rune
+	Combining []rune // Combining characters following Rune, if any
+	Style     tcell.Style
+	Width     int
+	// Wrapped is set on the last cell of a line when text continued onto the
+	// next line because it reached the right margin, rather than because of a
+	// line feed.
+	Wrapped bool
+}
+
+// blankCell returns an empty cell with the style.
+func blankCell(style tcell.Style) Cell {
+	return Cell{Rune: ' ', Style: style, Width: 1}
+}
+
+// MouseMode is the kind of mouse reporting an application running inside the
+// terminal has requested. Mouse events should only be forwarded to the
+// application if the mode is not MouseNone.
+type MouseMode uint8
+
+const (
+	MouseNone   MouseMode = iota
+	MouseX10              // Report button presses only
+	MouseNormal           // Report button presses and releases
+	MouseButton           // Also report motion while a button is held
+	MouseAny              // Report all motion
+)
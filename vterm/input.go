@@ -0,0 +1,192 @@
+package vterm
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// cursorKeys are the final bytes of the sequences for keys sent as CSI or SS3
+// sequences, like ESC [ A for Up.
+var cursorKeys = map[tcell.Key]byte{
+	tcell.KeyUp:    'A',
+	tcell.KeyDown:  'B',
+	tcell.KeyRight: 'C',
+	tcell.KeyLeft:  'D',
+	tcell.KeyHome:  'H',
+	tcell.KeyEnd:   'F',
+	tcell.KeyF1:    'P',
+	tcell.KeyF2:    'Q',
+	tcell.KeyF3:    'R',
+	tcell.KeyF4:    'S',
+}
+
+// tildeKeys are the numbers of the sequences for keys sent like ESC [ 3 ~ for
+// Delete.
+var tildeKeys = map[tcell.Key]int{
+	tcell.KeyInsert: 2,
+	tcell.KeyDelete: 3,
+	tcell.KeyPgUp:   5,
+	tcell.KeyPgDn:   6,
+	tcell.KeyF5:     15,
+	tcell.KeyF6:     17,
+	tcell.KeyF7:     18,
+	tcell.KeyF8:     19,
+	tcell.KeyF9:     20,
+	tcell.KeyF10:    21,
+	tcell.KeyF11:    23,
+	tcell.KeyF12:    24,
+}
+
+// KeySequence returns the bytes an xterm sends for the key event. If appCursor
+// is true, cursor keys are sent as application sequences, see
+// Screen.AppCursorKeys. Returns nil if the key has no sequence.
+func KeySequence(ev *tcell.EventKey, appCursor bool) []byte {
+	mods := ev.Modifiers()
+	// The xterm modifier parameter: 1 + Shift(1) + Alt(2) + Ctrl(4)
+	modParam := 1
+	if mods&tcell.ModShift != 0 {
+		modParam += 1
+	}
+	if mods&tcell.ModAlt != 0 {
+		modParam += 2
+	}
+	if mods&tcell.ModCtrl != 0 {
+		modParam += 4
+	}
+
+	key := ev.Key()
+	switch {
+	case key == tcell.KeyRune:
+		seq := make([]byte, 0, utf8.UTFMax+1)
+		if mods&tcell.ModAlt != 0 {
+			seq = append(seq, 0x1b)
+		}
+		var buf [utf8.UTFMax]byte
+		n := utf8.EncodeRune(buf[:], ev.Rune())
+		return append(seq, buf[:n]...)
+	case key == tcell.KeyBacktab:
+		return []byte("\x1b[Z")
+	case key < 256: // Control characters, Tab, Enter, Escape and Backspace
+		if mods&tcell.ModAlt != 0 {
+			return []byte{0x1b, byte(key)}
+		}
+		return []byte{byte(key)}
+	}
+
+	if final, ok := cursorKeys[key]; ok {
+		if modParam > 1 {
+			return []byte(fmt.Sprintf("\x1b[1;%d%c", modParam, final))
+		}
+		isFunctionKey := key >= tcell.KeyF1 && key <= tcell.KeyF4
+		if appCursor || isFunctionKey {
+			return []byte{0x1b, 'O', final}
+		}
+		return []byte{0x1b, '[', final}
+	}
+	if n, ok := tildeKeys[key]; ok {
+		if modParam > 1 {
+			return []byte(fmt.Sprintf("\x1b[%d;%d~", n, modParam))
+		}
+		return []byte(fmt.Sprintf("\x1b[%d~", n))
+	}
+	return nil
+}
+
+// PasteSequence returns the bytes an xterm sends when the text is pasted. If
+// bracketed is true, see Screen.BracketedPaste, the text is surrounded by
+// ESC [ 200 ~ and ESC [ 201 ~, and any of those sequences inside the text are
+// removed so it cannot end the paste early. Newlines are sent as carriage
+// returns, like typing Enter.
+func PasteSequence(text string, bracketed bool) []byte {
+	text = strings.ReplaceAll(text, "\r\n", "\r")
+	text = strings.ReplaceAll(text, "\n", "\r")
+	if !bracketed {
+		return []byte(text)
+	}
+	text = strings.ReplaceAll(text, "\x1b[200~", "")
+	text = strings.ReplaceAll(text, "\x1b[201~", "")
+	return []byte("\x1b[200~" + text + "\x1b[201~")
+}
+
+// MouseSequence returns the bytes an xterm reports for the mouse event at the
+// column x and row y of the Screen, starting from zero. The buttons held during
+// the previous event are needed to tell presses, releases and drags apart.
+// Returns nil if the mode does not report the event.
+func MouseSequence(ev *tcell.EventMouse, x, y int, prevButtons tcell.ButtonMask, mode MouseMode, sgr bool) []byte {
+	if mode == MouseNone {
+		return nil
+	}
+
+	buttons := ev.Buttons()
+	held := buttons & (tcell.Button1 | tcell.Button2 | tcell.Button3)
+	prevHeld := prevButtons & (tcell.Button1 | tcell.Button2 | tcell.Button3)
+
+	var code int
+	release := false
+	switch {
+	case buttons&tcell.WheelUp != 0:
+		code = 64
+	case buttons&tcell.WheelDown != 0:
+		code = 65
+	case held != 0 && held&^prevHeld != 0: // Pressed a new button
+		code = buttonCode(held &^ prevHeld)
+	case held != 0: // Dragging
+		if mode != MouseButton && mode != MouseAny {
+			return nil
+		}
+		code = buttonCode(held) + 32
+	case prevHeld != 0: // Released
+		if mode == MouseX10 {
+			return nil
+		}
+		release = true
+		code = 3
+		if sgr {
+			code = buttonCode(prevHeld)
+		}
+	default: // Moving without buttons
+		if mode != MouseAny {
+			return nil
+		}
+		code = 3 + 32
+	}
+
+	if mode != MouseX10 {
+		mods := ev.Modifiers()
+		if mods&tcell.ModShift != 0 {
+			code += 4
+		}
+		if mods&tcell.ModAlt != 0 {
+			code += 8
+		}
+		if mods&tcell.ModCtrl != 0 {
+			code += 16
+		}
+	}
+
+	if sgr {
+		final := 'M'
+		if release {
+			final = 'm'
+		}
+		return []byte(fmt.Sprintf("\x1b[<%d;%d;%d%c", code, x+1, y+1, final))
+	}
+	// Coordinates are encoded as single bytes, so they are limited to 223
+	return []byte{0x1b, '[', 'M', byte(32 + code), byte(32 + 1 + Clamp(x, 0, 222)), byte(32 + 1 + Clamp(y, 0, 222))}
+}
+
+// buttonCode returns the code of the lowest button in the mask.
+func buttonCode(buttons tcell.ButtonMask) int {
+	switch {
+	case buttons&tcell.Button1 != 0:
+		return 0
+	case buttons&tcell.Button3 != 0: // Middle
+		return 1
+	case buttons&tcell.Button2 != 0: // Right
+		return 2
+	}
+	return 3
+}
@@ -0,0 +1,466 @@
+package vterm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits on the sequences kept by the parser, like those of xterm, so a stream
+// of parameters or an unterminated string cannot grow without bound. Bytes past
+// a limit are dropped.
+const (
+	maxParamBytes = 256   // Length of the parameter string of a CSI sequence
+	maxParams     = 30    // Number of parameters of a CSI sequence
+	maxParamValue = 65535 // Value of each parameter
+	maxOSCBytes   = 4096  // Length of an OSC string
+)
+
+type parserState uint8
+
+const (
+	stateGround parserState = iota
+	stateEscape
+	stateEscapeIntermediate
+	stateCSI
+	stateOSC
+	stateString // DCS, SOS, PM and APC strings, which are ignored
+)
+
+// A parser interprets a stream of bytes as text and escape sequences, following
+// the state machine of a DEC VT500 series terminal, and applies them to a
+// Screen.
+type parser struct {
+	state        parserState
+	params       []byte // Parameter bytes of a CSI sequence
+	intermediate []byte // Intermediate bytes of an escape or CSI sequence
+	osc          []byte // Contents of an OSC string
+	stringEsc    bool   // Previous byte of an OSC or ignored string was ESC
+	partial      []byte // Incomplete UTF-8 sequence at the end of the last data
+}
+
+func (p *parser) parse(s *Screen, data []byte) {
+	if len(p.partial) > 0 {
+		data = append(p.partial, data...)
+		p.partial = nil
+	}
+	for len(data) > 0 {
+		b := data[0]
+		if p.state == stateGround && b >= 0x20 && b != 0x7f {
+			if !utf8.FullRune(data) {
+				p.partial = append([]byte(nil), data...) // Wait for the rest
+				return
+			}
+			r, size := utf8.DecodeRune(data)
+			s.putRune(r)
+			data = data[size:]
+			continue
+		}
+		p.parseByte(s, b)
+		data = data[1:]
+	}
+}
+
+func (p *parser) parseByte(s *Screen, b byte) {
+	// Strings are terminated by BEL or ST (ESC \), and may contain control bytes
+	switch p.state {
+	case stateOSC, stateString:
+		if p.stringEsc {
+			p.stringEsc = false
+			if b == '\\' {
+				p.endString(s)
+				return
+			}
+			if p.state == stateOSC && len(p.osc) < maxOSCBytes {
+				p.osc = append(p.osc, 0x1b)
+			}
+		}
+		switch b {
+		case 0x07:
+			p.endString(s)
+		case 0x1b:
+			p.stringEsc = true
+		default:
+			if p.state == stateOSC && len(p.osc) < maxOSCBytes {
+				p.osc = append(p.osc, b)
+			}
+		}
+		return
+	}
+
+	// Control bytes are executed immediately, even within a sequence
+	switch b {
+	case 0x18, 0x1a: // CAN, SUB cancel a sequence
+		p.state = stateGround
+		return
+	case 0x1b:
+		p.state = stateEscape
+		p.intermediate = p.intermediate[:0]
+		return
+	case 0x7f:
+		return // DEL is ignored
+	}
+	if b < 0x20 {
+		p.execute(s, b)
+		return
+	}
+
+	switch p.state {
+	case stateEscape, stateEscapeIntermediate:
+		if b >= 0x20 && b <= 0x2f {
+			p.intermediate = append(p.intermediate, b)
+			p.state = stateEscapeIntermediate
+			return
+		}
+		p.state = stateGround
+		if p.escDispatch(s, b) {
+			return
+		}
+	case stateCSI:
+		switch {
+		case b >= 0x30 && b <= 0x3f:
+			if len(p.params) < maxParamBytes {
+				p.params = append(p.params, b)
+			}
+		case b >= 0x20 && b <= 0x2f:
+			p.intermediate = append(p.intermediate, b)
+		default:
+			p.state = stateGround
+			p.csiDispatch(s, b)
+		}
+	}
+}
+
+func (p *parser) endString(s *Screen) {
+	if p.state == stateOSC {
+		p.oscDispatch(s, string(p.osc))
+	}
+	p.state = stateGround
+}
+
+// execute performs a C0 control function.
+func (p *parser) execute(s *Screen, b byte) {
+	switch b {
+	case 0x08: // BS
+		if s.wrapPending {
+			s.wrapPending = false
+		} else if s.cursorX > 0 {
+			s.cursorX--
+		}
+	case 0x09: // HT
+		s.tab(1)
+	case 0x0a, 0x0b, 0x0c: // LF, VT, FF
+		s.lineFeed()
+		if s.newlineMode {
+			s.cursorX = 0
+		}
+		s.wrapPending = false
+	case 0x0d: // CR
+		s.cursorX = 0
+		s.wrapPending = false
+	case 0x0e: // SO
+		s.shifted = true
+	case 0x0f: // SI
+		s.shifted = false
+	}
+}
+
+// escDispatch performs an escape sequence ending with the final byte. Returns
+// true if the sequence began another state, like CSI.
+func (p *parser) escDispatch(s *Screen, final byte) bool {
+	if len(p.intermediate) > 0 {
+		switch p.intermediate[0] {
+		case '(':
+			s.charset[0] = final
+		case ')':
+			s.charset[1] = final
+		case '#':
+			if final == '8' { // DECALN fills the screen with 'E'
+				for y := range s.lines {
+					for x := range s.lines[y] {
+						s.lines[y][x] = Cell{Rune: 'E', Style: s.DefaultStyle, Width: 1}
+					}
+				}
+			}
+		}
+		return false
+	}
+
+	switch final {
+	case '[':
+		p.state = stateCSI
+		p.params = p.params[:0]
+		p.intermediate = p.intermediate[:0]
+		return true
+	case ']':
+		p.state = stateOSC
+		p.osc = p.osc[:0]
+		p.stringEsc = false
+		return true
+	case 'P', 'X', '^', '_': // DCS, SOS, PM, APC
+		p.state = stateString
+		p.stringEsc = false
+		return true
+	case '7':
+		s.saveCursor()
+	case '8':
+		s.restoreCursor()
+	case 'D': // IND
+		s.lineFeed()
+		s.wrapPending = false
+	case 'E': // NEL
+		s.lineFeed()
+		s.cursorX = 0
+		s.wrapPending = false
+	case 'M': // RI
+		s.reverseIndex()
+	case 'H': // HTS
+		s.tabStops[s.cursorX] = true
+	case 'c': // RIS
+		s.reset()
+	case '=':
+		s.appKeypad = true
+	case '>':
+		s.appKeypad = false
+	}
+	return false
+}
+
+// csiParams parses the parameters of a CSI sequence. Returns the private marker
+// byte (like '?'), if any, and each parameter separated by semicolons. Each
+// parameter is a list of sub-parameters separated by colons, where -1 is an
+// omitted sub-parameter. Parameters after maxParams are ignored, and values
+// are clamped to maxParamValue.
+func (p *parser) csiParams() (marker byte, params [][]int) {
+	str := string(p.params)
+	if len(str) > 0 && str[0] >= '<' && str[0] <= '?' {
+		marker = str[0]
+		str = str[1:]
+	}
+	if str == "" {
+		return marker, nil
+	}
+	for _, field := range strings.SplitN(str, ";", maxParams+1) {
+		if len(params) == maxParams {
+			break
+		}
+		var subs []int
+		for _, sub := range strings.Split(field, ":") {
+			if n, err := strconv.Atoi(sub); err == nil {
+				subs = append(subs, Min(n, maxParamValue))
+			} else if err.(*strconv.NumError).Err == strconv.ErrRange {
+				subs = append(subs, maxParamValue)
+			} else {
+				subs = append(subs, -1)
+			}
+		}
+		params = append(params, subs)
+	}
+	return marker, params
+}
+
+// param returns the parameter at idx, or def if it was omitted or zero.
+func param(params [][]int, idx, def int) int {
+	if idx < len(params) && params[idx][0] > 0 {
+		return params[idx][0]
+	}
+	return def
+}
+
+func (p *parser) csiDispatch(s *Screen, final byte) {
+	marker, params := p.csiParams()
+	if len(p.intermediate) > 0 {
+		return // DECSCUSR and others are not supported
+	}
+
+	if marker == '?' {
+		switch final {
+		case 'h':
+			for _, mode := range params {
+				s.setPrivateMode(mode[0], true)
+			}
+		case 'l':
+			for _, mode := range params {
+				s.setPrivateMode(mode[0], false)
+			}
+		}
+		return
+	} else if marker != 0 {
+		return
+	}
+
+	n := param(params, 0, 1)
+	switch final {
+	case '@': // ICH
+		s.insertChars(n)
+	case 'A': // CUU
+		s.moveCursor(0, -n)
+	case 'B', 'e': // CUD, VPR
+		s.moveCursor(0, n)
+	case 'C', 'a': // CUF, HPR
+		s.moveCursor(n, 0)
+	case 'D': // CUB
+		s.moveCursor(-n, 0)
+	case 'E': // CNL
+		s.moveCursor(0, n)
+		s.cursorX = 0
+	case 'F': // CPL
+		s.moveCursor(0, -n)
+		s.cursorX = 0
+	case 'G', '`': // CHA, HPA
+		s.cursorX = Clamp(n-1, 0, s.width-1)
+		s.wrapPending = false
+	case 'H', 'f': // CUP, HVP
+		s.setCursor(param(params, 1, 1)-1, n-1)
+	case 'I': // CHT
+		s.tab(n)
+	case 'J': // ED
+		s.eraseInDisplay(param(params, 0, 0))
+	case 'K': // EL
+		s.eraseInLine(param(params, 0, 0))
+	case 'L': // IL
+		s.insertLines(n)
+	case 'M': // DL
+		s.deleteLines(n)
+	case 'P': // DCH
+		s.deleteChars(n)
+	case 'S': // SU
+		s.scrollUp(n)
+	case 'T': // SD
+		s.scrollDown(n)
+	case 'X': // ECH
+		s.eraseRect(s.cursorX, s.cursorY, n, 1)
+		s.wrapPending = false
+	case 'Z': // CBT
+		s.tab(-n)
+	case 'b': // REP repeats the previous character
+		if x := s.cursorX - 1; x >= 0 && !s.wrapPending {
+			r := s.lines[s.cursorY][x].Rune
+			n = Min(n, s.width*s.height) // More would only overwrite the same cells
+			for i := 0; i < n && r != 0; i++ {
+				s.putRune(r)
+			}
+		}
+	case 'c': // DA
+		if param(params, 0, 0) == 0 {
+			s.reply([]byte("\x1b[?62;22c")) // VT220 with ANSI color
+		}
+	case 'd': // VPA
+		s.setCursor(s.cursorX, n-1)
+	case 'g': // TBC
+		switch param(params, 0, 0) {
+		case 0:
+			s.tabStops[s.cursorX] = false
+		case 3:
+			for i := range s.tabStops {
+				s.tabStops[i] = false
+			}
+		}
+	case 'h', 'l': // SM, RM
+		for _, mode := range params {
+			switch mode[0] {
+			case 4:
+				s.insertMode = final == 'h'
+			case 20:
+				s.newlineMode = final == 'h'
+			}
+		}
+	case 'm':
+		s.selectGraphicRendition(params)
+	case 'n': // DSR
+		switch param(params, 0, 0) {
+		case 5:
+			s.reply([]byte("\x1b[0n"))
+		case 6:
+			y := s.cursorY
+			if s.originMode {
+				y -= s.scrollTop
+			}
+			s.reply([]byte(fmt.Sprintf("\x1b[%d;%dR", y+1, Min(s.cursorX, s.width-1)+1)))
+		}
+	case 'r': // DECSTBM
+		top, bottom := param(params, 0, 1)-1, param(params, 1, s.height)-1
+		if bottom >= s.height {
+			bottom = s.height - 1
+		}
+		if top < bottom {
+			s.scrollTop, s.scrollBottom = top, bottom
+			s.setCursor(0, 0)
+		}
+	case 's': // SCOSC
+		s.saveCursor()
+	case 'u': // SCORC
+		s.restoreCursor()
+	}
+}
+
+// setPrivateMode performs DECSET and DECRST for the mode.
+func (s *Screen) setPrivateMode(mode int, on bool) {
+	switch mode {
+	case 1:
+		s.appCursorKeys = on
+	case 6:
+		s.originMode = on
+		s.setCursor(0, 0)
+	case 7:
+		s.autoWrap = on
+		if !on {
+			s.wrapPending = false
+		}
+	case 9:
+		s.setMouseMode(MouseX10, on)
+	case 25:
+		s.cursorVisible = on
+	case 47, 1047:
+		s.setAltScreen(on)
+	case 1000:
+		s.setMouseMode(MouseNormal, on)
+	case 1002:
+		s.setMouseMode(MouseButton, on)
+	case 1003:
+		s.setMouseMode(MouseAny, on)
+	case 1006:
+		s.mouseSGR = on
+	case 2004:
+		s.bracketPaste = on
+	case 1048:
+		if on {
+			s.saveCursor()
+		} else {
+			s.restoreCursor()
+		}
+	case 1049: // Save cursor and use the alternate screen
+		if on {
+			s.saveCursor()
+			s.setAltScreen(true)
+		} else {
+			s.setAltScreen(false)
+			s.restoreCursor()
+		}
+	}
+}
+
+func (s *Screen) setMouseMode(mode MouseMode, on bool) {
+	if on {
+		s.mouseMode = mode
+	} else if s.mouseMode == mode {
+		s.mouseMode = MouseNone
+	}
+}
+
+// oscDispatch performs an operating system command. Only setting the window
+// title is supported.
+func (p *parser) oscDispatch(s *Screen, command string) {
+	i := strings.IndexByte(command, ';')
+	if i == -1 {
+		return
+	}
+	switch command[:i] {
+	case "0", "2": // Icon name and window title, or only window title
+		s.title = command[i+1:]
+		if s.OnTitle != nil {
+			s.OnTitle(s.title)
+		}
+	}
+}
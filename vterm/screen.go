@@ -0,0 +1,579 @@
+package vterm
+
+import (
+	"io"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// A Screen is the model of a terminal display: a grid of cells, a cursor, and
+// the modes set by the application writing to it. A Screen is changed by the
+// output of an application, interpreted as VT100 and xterm escape sequences.
+// A Screen does not need a Terminal: output captured from anywhere, like a
+// build log or "git diff --color", can be written to it with Write.
+//
+// Coordinates start at zero from the top left of the Screen.
+type Screen struct {
+	// DefaultStyle is the style of empty cells and of text with no attributes.
+	DefaultStyle tcell.Style
+	// Reply receives responses to queries made by the application, like the
+	// cursor position. It should be the input of the application. Optional.
+	Reply io.Writer
+	// OnTitle is called when the application sets the window title. Optional.
+	OnTitle func(title string)
+
+	width, height int
+	lines         [][]Cell
+	altLines      [][]Cell // Lines of the inactive screen, main or alternate
+	altActive     bool     // True if the alternate screen is shown
+
+	cursorX, cursorY int
+	wrapPending      bool // The last column was written and the next rune wraps
+	style            tcell.Style
+	charset          [2]byte // Designated G0 and G1 character sets
+	shifted          bool    // True if G1 is invoked, otherwise G0
+	saved            savedCursor
+	scrollTop        int // First line of the scrolling region
+	scrollBottom     int // Last line of the scrolling region, inclusive
+	tabStops         []bool
+
+	autoWrap      bool
+	originMode    bool
+	insertMode    bool
+	newlineMode   bool
+	cursorVisible bool
+	appCursorKeys bool
+	appKeypad     bool
+	mouseMode     MouseMode
+	mouseSGR      bool
+	bracketPaste  bool
+	title         string
+
+	// MaxScrollback is the number of lines that scrolled off the top of the
+	// Screen to keep as history. Zero keeps none.
+	MaxScrollback int
+	scrollback    [][]Cell // Oldest line first
+	viewOffset    int      // Number of scrollback lines being viewed
+
+	parser parser
+}
+
+type savedCursor struct {
+	x, y        int
+	style       tcell.Style
+	charset     [2]byte
+	shifted     bool
+	originMode  bool
+	wrapPending bool
+}
+
+// NewScreen makes a Screen of the size filled with blank cells.
+func NewScreen(width, height int) *Screen {
+	s := &Screen{DefaultStyle: tcell.StyleDefault, MaxScrollback: DefaultMaxScrollback}
+	s.Resize(width, height)
+	s.reset()
+	return s
+}
+
+// reset returns the Screen to its initial state, preserving the size.
+func (s *Screen) reset() {
+	s.style = s.DefaultStyle
+	s.charset = [2]byte{'B', 'B'}
+	s.shifted = false
+	s.cursorX, s.cursorY = 0, 0
+	s.wrapPending = false
+	s.scrollTop, s.scrollBottom = 0, s.height-1
+	s.autoWrap = true
+	s.originMode = false
+	s.insertMode = false
+	s.newlineMode = false
+	s.cursorVisible = true
+	s.appCursorKeys = false
+	s.appKeypad = false
+	s.mouseMode = MouseNone
+	s.mouseSGR = false
+	s.bracketPaste = false
+	if s.altActive {
+		s.lines, s.altLines = s.altLines, s.lines
+		s.altActive = false
+	}
+	s.saved = savedCursor{style: s.style, charset: s.charset}
+	s.resetTabStops()
+	s.eraseRect(0, 0, s.width, s.height)
+}
+
+// Size returns the number of columns and rows of the Screen.
+func (s *Screen) Size() (width, height int) {
+	return s.width, s.height
+}
+
+// Cell returns the cell at the column x and row y. Positions outside the Screen
+// return a blank cell.
+func (s *Screen) Cell(x, y int) Cell {
+	if x < 0 || y < 0 || x >= s.width || y >= s.height {
+		return blankCell(s.DefaultStyle)
+	}
+	return s.lines[y][x]
+}
+
+// Cursor returns the position of the cursor, and whether the application wants
+// the cursor to be visible.
+func (s *Screen) Cursor() (x, y int, visible bool) {
+	return Min(s.cursorX, s.width-1), s.cursorY, s.cursorVisible
+}
+
+// MouseMode returns the kind of mouse reporting requested by the application,
+// and whether it expects SGR encoded mouse reports (mode 1006).
+func (s *Screen) MouseMode() (mode MouseMode, sgr bool) {
+	return s.mouseMode, s.mouseSGR
+}
+
+// AppCursorKeys returns true if the application requested that cursor keys send
+// application sequences (ESC O A) rather than normal sequences (ESC [ A).
+func (s *Screen) AppCursorKeys() bool {
+	return s.appCursorKeys
+}
+
+// AltScreen returns true if the alternate screen, used by full-screen
+// applications, is being shown.
+func (s *Screen) AltScreen() bool {
+	return s.altActive
+}
+
+// Title returns the window title last set by the application.
+func (s *Screen) Title() string {
+	return s.title
+}
+
+// BracketedPaste returns true if the application asked for pasted text to be
+// surrounded by escape sequences, see PasteSequence.
+func (s *Screen) BracketedPaste() bool {
+	return s.bracketPaste
+}
+
+// Wrapped returns true if row y of the Screen continues onto the next row,
+// because text reached the right margin.
+func (s *Screen) Wrapped(y int) bool {
+	return s.Cell(s.width-1, y).Wrapped
+}
+
+// Resize changes the size of the Screen. Columns are kept from the left. When
+// the height shrinks, lines are removed from the top only as needed to keep the
+// cursor on the Screen, and the rest are removed from the bottom.
+func (s *Screen) Resize(width, height int) {
+	width, height = Max(width, 1), Max(height, 1)
+	if width == s.width && height == s.height {
+		return
+	}
+	drop := Max(s.cursorY-(height-1), 0)
+	if main := s.mainLines(); drop > 0 {
+		for _, line := range main[:Min(drop, len(main))] {
+			s.pushScrollback(line)
+		}
+	}
+	s.lines = resizeLines(s.lines, drop, width, height, s.DefaultStyle)
+	if s.altLines != nil {
+		s.altLines = resizeLines(s.altLines, drop, width, height, s.DefaultStyle)
+	}
+	s.width, s.height = width, height
+	s.scrollTop, s.scrollBottom = 0, height-1
+	s.cursorX = Min(s.cursorX, width-1)
+	s.cursorY -= drop
+	s.wrapPending = false
+	s.resetTabStops()
+}
+
+// resizeLines removes drop lines from the top, then fits the lines to the size.
+func resizeLines(lines [][]Cell, drop, width, height int, style tcell.Style) [][]Cell {
+	lines = lines[Min(drop, len(lines)):]
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	for i := range lines {
+		wrapped := len(lines[i]) > 0 && lines[i][len(lines[i])-1].Wrapped
+		if wrapped {
+			lines[i][len(lines[i])-1].Wrapped = false
+		}
+		if len(lines[i]) > width {
+			lines[i] = lines[i][:width]
+			if last := &lines[i][width-1]; last.Width == 2 {
+				*last = blankCell(last.Style) // Wide rune was cut in half
+			}
+		}
+		for len(lines[i]) < width {
+			lines[i] = append(lines[i], blankCell(style))
+		}
+		lines[i][width-1].Wrapped = wrapped
+	}
+	for len(lines) < height {
+		lines = append(lines, newLine(width, style))
+	}
+	return lines
+}
+
+func newLine(width int, style tcell.Style) []Cell {
+	line := make([]Cell, width)
+	for i := range line {
+		line[i] = blankCell(style)
+	}
+	return line
+}
+
+func (s *Screen) resetTabStops() {
+	s.tabStops = make([]bool, s.width)
+	for i := 0; i < s.width; i += 8 {
+		s.tabStops[i] = true
+	}
+}
+
+// Write interprets the bytes as output of an application. A UTF-8 sequence or
+// escape sequence split across writes is completed by the following write.
+// It never returns an error.
+func (s *Screen) Write(p []byte) (int, error) {
+	s.parser.parse(s, p)
+	return len(p), nil
+}
+
+// WriteString is like Write but takes a string.
+func (s *Screen) WriteString(str string) (int, error) {
+	return s.Write([]byte(str))
+}
+
+func (s *Screen) reply(b []byte) {
+	if s.Reply != nil {
+		_, _ = s.Reply.Write(b)
+	}
+}
+
+// blankStyle is the style used for erased cells: the background of the current
+// style with no other attributes, like xterm.
+func (s *Screen) blankStyle() tcell.Style {
+	_, bg, _ := s.style.Decompose()
+	return s.DefaultStyle.Background(bg)
+}
+
+// putRune writes a printable rune at the cursor and advances it.
+func (s *Screen) putRune(r rune) {
+	r = s.translate(r)
+	width := runewidth.RuneWidth(r)
+	if width == 0 {
+		s.putCombining(r)
+		return
+	}
+
+	if s.wrapPending || s.cursorX+width > s.width {
+		if s.autoWrap {
+			s.lines[s.cursorY][s.width-1].Wrapped = true
+			s.cursorX = 0
+			s.lineFeed()
+		} else {
+			s.cursorX = Max(s.width-width, 0)
+		}
+		s.wrapPending = false
+	}
+	if width > s.width {
+		return // Cannot fit anywhere
+	}
+
+	line := s.lines[s.cursorY]
+	if s.insertMode {
+		copy(line[s.cursorX+width:], line[s.cursorX:])
+	}
+	s.clearWide(s.cursorX, s.cursorY)
+	if width == 2 {
+		s.clearWide(s.cursorX+1, s.cursorY)
+	}
+	line[s.cursorX] = Cell{Rune: r, Style: s.style, Width: width}
+	if width == 2 {
+		line[s.cursorX+1] = Cell{Style: s.style, Width: 0}
+	}
+
+	if s.cursorX+width >= s.width {
+		s.cursorX = s.width - 1
+		s.wrapPending = true
+	} else {
+		s.cursorX += width
+	}
+}
+
+// putCombining attaches a zero-width rune to the previous cell.
+func (s *Screen) putCombining(r rune) {
+	x, y := s.cursorX-1, s.cursorY
+	if s.wrapPending {
+		x = s.cursorX
+	}
+	if x < 0 {
+		return
+	}
+	if s.lines[y][x].Width == 0 && x > 0 {
+		x-- // Second half of a wide rune
+	}
+	s.lines[y][x].Combining = append(s.lines[y][x].Combining, r)
+}
+
+// clearWide blanks both halves of a wide rune if the cell is a part of one.
+func (s *Screen) clearWide(x, y int) {
+	if x < 0 || x >= s.width {
+		return
+	}
+	line := s.lines[y]
+	if line[x].Width == 2 && x+1 < s.width {
+		line[x+1] = blankCell(line[x].Style)
+	} else if line[x].Width == 0 && x > 0 {
+		line[x-1] = blankCell(line[x-1].Style)
+	}
+	line[x] = blankCell(line[x].Style)
+}
+
+// decSpecialGraphics maps the DEC special graphics character set, used by many
+// applications to draw lines, to Unicode.
+var decSpecialGraphics = map[rune]rune{
+	'`': '◆', 'a': '▒', 'f': '°', 'g': '±', 'j': '┘', 'k': '┐', 'l': '┌',
+	'm': '└', 'n': '┼', 'o': '⎺', 'p': '⎻', 'q': '─', 'r': '⎼', 's': '⎽',
+	't': '├', 'u': '┤', 'v': '┴', 'w': '┬', 'x': '│', 'y': '≤', 'z': '≥',
+	'{': 'π', '|': '≠', '}': '£', '~': '·',
+}
+
+func (s *Screen) translate(r rune) rune {
+	set := s.charset[0]
+	if s.shifted {
+		set = s.charset[1]
+	}
+	if set == '0' {
+		if g, ok := decSpecialGraphics[r]; ok {
+			return g
+		}
+	}
+	return r
+}
+
+// lineFeed moves the cursor down, scrolling the region if the cursor is at its
+// bottom.
+func (s *Screen) lineFeed() {
+	if s.cursorY == s.scrollBottom {
+		s.scrollUp(1)
+	} else if s.cursorY < s.height-1 {
+		s.cursorY++
+	}
+}
+
+// reverseIndex moves the cursor up, scrolling the region down if the cursor is
+// at its top.
+func (s *Screen) reverseIndex() {
+	s.wrapPending = false
+	if s.cursorY == s.scrollTop {
+		s.scrollDown(1)
+	} else if s.cursorY > 0 {
+		s.cursorY--
+	}
+}
+
+// scrollUp moves the lines of the scrolling region up by n, adding blank lines
+// at the bottom.
+func (s *Screen) scrollUp(n int) {
+	// Lines leaving the top of the main screen are kept as history, like xterm
+	if s.scrollTop == 0 && !s.altActive {
+		for i := 0; i < Min(n, s.scrollBottom+1); i++ {
+			s.pushScrollback(s.lines[i])
+		}
+	}
+	s.scrollLinesUp(s.scrollTop, s.scrollBottom, n)
+}
+
+func (s *Screen) scrollDown(n int) {
+	s.scrollLinesDown(s.scrollTop, s.scrollBottom, n)
+}
+
+func (s *Screen) scrollLinesUp(top, bottom, n int) {
+	n = Min(n, bottom-top+1)
+	if n <= 0 {
+		return
+	}
+	removed := make([][]Cell, n)
+	copy(removed, s.lines[top:top+n])
+	copy(s.lines[top:], s.lines[top+n:bottom+1])
+	for i := 0; i < n; i++ {
+		line := removed[i] // Reuse the memory of removed lines
+		for x := range line {
+			line[x] = blankCell(s.blankStyle())
+		}
+		s.lines[bottom-n+1+i] = line
+	}
+}
+
+func (s *Screen) scrollLinesDown(top, bottom, n int) {
+	n = Min(n, bottom-top+1)
+	if n <= 0 {
+		return
+	}
+	removed := make([][]Cell, n)
+	copy(removed, s.lines[bottom-n+1:bottom+1])
+	copy(s.lines[top+n:], s.lines[top:bottom-n+1])
+	for i := 0; i < n; i++ {
+		line := removed[i]
+		for x := range line {
+			line[x] = blankCell(s.blankStyle())
+		}
+		s.lines[top+i] = line
+	}
+}
+
+// eraseRect blanks the cells in the rectangle, using the blank style.
+func (s *Screen) eraseRect(x, y, w, h int) {
+	style := s.blankStyle()
+	for row := Max(y, 0); row < Min(y+h, s.height); row++ {
+		for col := Max(x, 0); col < Min(x+w, s.width); col++ {
+			s.clearWide(col, row)
+			s.lines[row][col] = blankCell(style)
+		}
+	}
+}
+
+// setCursor moves the cursor, clamped within the Screen, or within the
+// scrolling region if origin mode is set. The row is relative to the region in
+// origin mode.
+func (s *Screen) setCursor(x, y int) {
+	top, bottom := 0, s.height-1
+	if s.originMode {
+		top, bottom = s.scrollTop, s.scrollBottom
+		y += top
+	}
+	s.cursorX = Clamp(x, 0, s.width-1)
+	s.cursorY = Clamp(y, top, bottom)
+	s.wrapPending = false
+}
+
+// moveCursor moves the cursor relative to its position. Vertical movement stops
+// at the scrolling region edges if the cursor starts inside it.
+func (s *Screen) moveCursor(dx, dy int) {
+	top, bottom := 0, s.height-1
+	if s.cursorY >= s.scrollTop && s.cursorY <= s.scrollBottom {
+		top, bottom = s.scrollTop, s.scrollBottom
+	}
+	s.cursorX = Clamp(s.cursorX+dx, 0, s.width-1)
+	s.cursorY = Clamp(s.cursorY+dy, top, bottom)
+	s.wrapPending = false
+}
+
+func (s *Screen) tab(n int) {
+	for ; n > 0 && s.cursorX < s.width-1; n-- {
+		s.cursorX++
+		for s.cursorX < s.width-1 && !s.tabStops[s.cursorX] {
+			s.cursorX++
+		}
+	}
+	for ; n < 0 && s.cursorX > 0; n++ {
+		s.cursorX--
+		for s.cursorX > 0 && !s.tabStops[s.cursorX] {
+			s.cursorX--
+		}
+	}
+	s.wrapPending = false
+}
+
+func (s *Screen) saveCursor() {
+	s.saved = savedCursor{s.cursorX, s.cursorY, s.style, s.charset, s.shifted, s.originMode, s.wrapPending}
+}
+
+func (s *Screen) restoreCursor() {
+	s.cursorX = Min(s.saved.x, s.width-1)
+	s.cursorY = Min(s.saved.y, s.height-1)
+	s.style = s.saved.style
+	s.charset = s.saved.charset
+	s.shifted = s.saved.shifted
+	s.originMode = s.saved.originMode
+	s.wrapPending = s.saved.wrapPending
+}
+
+// setAltScreen switches between the main and alternate screens. The alternate
+// screen is cleared when it is shown.
+func (s *Screen) setAltScreen(on bool) {
+	if on == s.altActive {
+		return
+	}
+	if s.altLines == nil {
+		s.altLines = resizeLines(nil, 0, s.width, s.height, s.DefaultStyle)
+	}
+	s.lines, s.altLines = s.altLines, s.lines
+	s.altActive = on
+	if on {
+		s.eraseRect(0, 0, s.width, s.height)
+	}
+}
+
+// eraseInDisplay implements ED: 0 erases below the cursor, 1 above it, 2 the
+// entire screen, and 3 the entire screen and scrollback.
+func (s *Screen) eraseInDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.eraseRect(s.cursorX, s.cursorY, s.width, 1)
+		s.eraseRect(0, s.cursorY+1, s.width, s.height)
+	case 1:
+		s.eraseRect(0, 0, s.width, s.cursorY)
+		s.eraseRect(0, s.cursorY, s.cursorX+1, 1)
+	case 2:
+		s.eraseRect(0, 0, s.width, s.height)
+	case 3: // Erases the scrollback, too, like xterm
+		s.eraseRect(0, 0, s.width, s.height)
+		s.ClearScrollback()
+	}
+	s.wrapPending = false
+}
+
+// eraseInLine implements EL: 0 erases right of the cursor, 1 left of it, and 2
+// the entire line.
+func (s *Screen) eraseInLine(mode int) {
+	switch mode {
+	case 0:
+		s.eraseRect(s.cursorX, s.cursorY, s.width, 1)
+	case 1:
+		s.eraseRect(0, s.cursorY, s.cursorX+1, 1)
+	case 2:
+		s.eraseRect(0, s.cursorY, s.width, 1)
+	}
+	s.wrapPending = false
+}
+
+// insertLines implements IL, inserting blank lines at the cursor within the
+// scrolling region.
+func (s *Screen) insertLines(n int) {
+	if s.cursorY < s.scrollTop || s.cursorY > s.scrollBottom {
+		return
+	}
+	s.scrollLinesDown(s.cursorY, s.scrollBottom, n)
+	s.cursorX = 0
+	s.wrapPending = false
+}
+
+// deleteLines implements DL, removing lines at the cursor within the scrolling
+// region.
+func (s *Screen) deleteLines(n int) {
+	if s.cursorY < s.scrollTop || s.cursorY > s.scrollBottom {
+		return
+	}
+	s.scrollLinesUp(s.cursorY, s.scrollBottom, n)
+	s.cursorX = 0
+	s.wrapPending = false
+}
+
+// insertChars implements ICH, shifting the rest of the line right.
+func (s *Screen) insertChars(n int) {
+	line := s.lines[s.cursorY]
+	n = Min(n, s.width-s.cursorX)
+	s.clearWide(s.cursorX, s.cursorY)
+	copy(line[s.cursorX+n:], line[s.cursorX:])
+	s.eraseRect(s.cursorX, s.cursorY, n, 1)
+	s.wrapPending = false
+}
+
+// deleteChars implements DCH, shifting the rest of the line left.
+func (s *Screen) deleteChars(n int) {
+	line := s.lines[s.cursorY]
+	n = Min(n, s.width-s.cursorX)
+	s.clearWide(s.cursorX, s.cursorY)
+	s.clearWide(s.cursorX+n-1, s.cursorY)
+	copy(line[s.cursorX:], line[s.cursorX+n:])
+	s.eraseRect(s.width-n, s.cursorY, n, 1)
+	s.wrapPending = false
+}
@@ -0,0 +1,243 @@
+package vterm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// screenText returns the text of a row of the Screen, without trailing spaces.
+func screenText(s *Screen, y int) string {
+	return s.HistoryText(s.ScrollbackLines() + y)
+}
+
+// expectLines fails the test if the rows of the Screen do not begin with the
+// lines.
+func expectLines(t *testing.T, s *Screen, lines ...string) {
+	t.Helper()
+	for y, want := range lines {
+		if got := screenText(s, y); got != want {
+			t.Errorf("line %d is %q, expected %q", y, got, want)
+		}
+	}
+}
+
+func expectCursor(t *testing.T, s *Screen, x, y int) {
+	t.Helper()
+	if cx, cy, _ := s.Cursor(); cx != x || cy != y {
+		t.Errorf("cursor is at %d, %d, expected %d, %d", cx, cy, x, y)
+	}
+}
+
+func TestScreenCursorMovement(t *testing.T) {
+	s := NewScreen(10, 5)
+	s.WriteString("\x1b[3;4H") // CUP is one-based
+	expectCursor(t, s, 3, 2)
+	s.WriteString("\x1b[2A\x1b[5C")
+	expectCursor(t, s, 8, 0)
+	s.WriteString("\x1b[99B\x1b[99D")
+	expectCursor(t, s, 0, 4)
+	s.WriteString("\x1b[7G\x1b[2d")
+	expectCursor(t, s, 6, 1)
+	s.WriteString("ab\r\ncd")
+	expectCursor(t, s, 2, 2)
+	expectLines(t, s, "", "      ab", "cd")
+
+	s.WriteString("\x1b7\x1b[H\x1b8") // Save and restore
+	expectCursor(t, s, 2, 2)
+}
+
+func TestScreenAutoWrap(t *testing.T) {
+	s := NewScreen(4, 3)
+	s.WriteString("abcdef")
+	expectLines(t, s, "abcd", "ef")
+	if !s.Wrapped(0) || s.Wrapped(1) {
+		t.Error("only the first line should be wrapped")
+	}
+
+	s = NewScreen(4, 3)
+	s.WriteString("\x1b[?7labcdef") // Without autowrap, the last column is overwritten
+	expectLines(t, s, "abcf", "")
+}
+
+func TestScreenErase(t *testing.T) {
+	s := NewScreen(5, 3)
+	s.WriteString("abcde\r\nfghij\r\nklmno")
+
+	s.WriteString("\x1b[2;3H\x1b[K") // To the end of the line
+	expectLines(t, s, "abcde", "fg", "klmno")
+	s.WriteString("\x1b[1K") // To the start of the line
+	expectLines(t, s, "abcde", "", "klmno")
+	s.WriteString("\x1b[1;2H\x1b[2X") // Characters
+	expectLines(t, s, "a  de", "", "klmno")
+	s.WriteString("\x1b[3;4H\x1b[1J") // To the start of the display
+	expectLines(t, s, "", "", "    o")
+	s.WriteString("\x1b[2J")
+	expectLines(t, s, "", "", "")
+}
+
+func TestScreenScrollRegion(t *testing.T) {
+	s := NewScreen(3, 5)
+	s.MaxScrollback = 0
+	s.WriteString("1\r\n2\r\n3\r\n4\r\n5")
+
+	s.WriteString("\x1b[2;4r") // Lines 2 to 4
+	expectCursor(t, s, 0, 0)   // DECSTBM homes the cursor
+	s.WriteString("\x1b[4H\n")
+	expectLines(t, s, "1", "3", "4", "", "5")
+
+	s.WriteString("\x1b[2H\x1bM") // Reverse index at the top of the region
+	expectLines(t, s, "1", "", "3", "4", "5")
+
+	s.WriteString("\x1b[3H\x1b[L") // Insert a line inside the region
+	expectLines(t, s, "1", "", "", "3", "5")
+	s.WriteString("\x1b[2M")
+	expectLines(t, s, "1", "", "", "", "5")
+
+	s.WriteString("\x1b[r\x1b[5H\nx") // Reset the region to the whole Screen
+	expectLines(t, s, "", "", "", "5", "x")
+}
+
+func TestScreenSGR(t *testing.T) {
+	s := NewScreen(10, 1)
+	s.WriteString("\x1b[1;31ma\x1b[38;5;208;48;5;17mb\x1b[38;2;1;2;3mc\x1b[48:2::4:5:6md\x1b[0me")
+
+	tests := []struct {
+		fg, bg tcell.Color
+		bold   bool
+	}{
+		{tcell.PaletteColor(1), tcell.ColorDefault, true},
+		{tcell.PaletteColor(208), tcell.PaletteColor(17), true},
+		{tcell.NewRGBColor(1, 2, 3), tcell.PaletteColor(17), true},
+		{tcell.NewRGBColor(1, 2, 3), tcell.NewRGBColor(4, 5, 6), true},
+		{tcell.ColorDefault, tcell.ColorDefault, false},
+	}
+	for x, test := range tests {
+		fg, bg, attrs := s.Cell(x, 0).Style.Decompose()
+		if fg != test.fg || bg != test.bg || (attrs&tcell.AttrBold != 0) != test.bold {
+			t.Errorf("cell %d has fg %v, bg %v, attributes %v", x, fg, bg, attrs)
+		}
+	}
+}
+
+func TestScreenWideRune(t *testing.T) {
+	s := NewScreen(4, 2)
+	s.WriteString("a世b")
+	if c := s.Cell(1, 0); c.Rune != '世' || c.Width != 2 || s.Cell(2, 0).Width != 0 {
+		t.Errorf("wide rune was not written to two cells")
+	}
+	expectLines(t, s, "a世b")
+
+	s = NewScreen(1, 2)
+	s.WriteString("\x1b[?7l世a") // Cannot fit without autowrap, so is dropped
+	expectLines(t, s, "a")
+}
+
+func TestScreenRepeat(t *testing.T) {
+	s := NewScreen(4, 2)
+	s.WriteString("x\x1b[2b")
+	expectLines(t, s, "xxx")
+
+	s.WriteString("\x1b[9223372036854775807b") // Must not hang
+	expectLines(t, s, "xxxx", "xxx")           // Clamped to the cells of the Screen
+}
+
+func TestScreenSplitRune(t *testing.T) {
+	s := NewScreen(4, 1)
+	s.WriteString("a\xe4\xb8") // The first bytes of 世
+	s.WriteString("\x96b")     // And the rest
+	if c := s.Cell(1, 0); c.Rune != '世' {
+		t.Errorf("cell 1 is %q", c.Rune)
+	}
+	expectLines(t, s, "a世b")
+}
+
+func TestScreenTitle(t *testing.T) {
+	tests := []struct {
+		data, title string
+	}{
+		{"\x1b]2;bell\x07", "bell"},
+		{"\x1b]0;string terminator\x1b\\", "string terminator"},
+		{"\x1b]2;split", ""}, // Not yet terminated
+		{"\x1b]2;a\x1bb\x07", "a\x1bb"},
+		{"\x1b]1;icon only\x07", ""},
+	}
+	for _, test := range tests {
+		s := NewScreen(4, 1)
+		var called string
+		s.OnTitle = func(title string) { called = title }
+		s.WriteString(test.data + "x")
+		if s.Title() != test.title || called != test.title {
+			t.Errorf("%q set the title %q and called OnTitle with %q", test.data, s.Title(), called)
+		}
+	}
+
+	s := NewScreen(4, 1)
+	s.WriteString("\x1b]2;" + strings.Repeat("t", 2*maxOSCBytes) + "\x07x")
+	if len(s.Title()) != maxOSCBytes-2 {
+		t.Errorf("title is %d bytes long", len(s.Title()))
+	}
+	expectLines(t, s, "x")
+}
+
+func TestScreenLongParams(t *testing.T) {
+	s := NewScreen(10, 10)
+	s.WriteString("\x1b[" + strings.Repeat("1;", 1000) + "5H") // Too many to reach 5
+	expectCursor(t, s, 0, 0)
+	s.WriteString("\x1b[99999999999999999999;3H") // Too large for an int
+	expectCursor(t, s, 2, 9)
+
+	p := &parser{params: []byte(strings.Repeat("7;", 100))}
+	if _, params := p.csiParams(); len(params) != maxParams {
+		t.Errorf("%d parameters were kept", len(params))
+	}
+}
+
+func TestScreenBracketedPaste(t *testing.T) {
+	s := NewScreen(4, 1)
+	if s.BracketedPaste() {
+		t.Fatal("bracketed paste is on by default")
+	}
+	s.WriteString("\x1b[?2004h")
+	if !s.BracketedPaste() {
+		t.Fatal("bracketed paste was not turned on")
+	}
+	tests := []struct {
+		text      string
+		bracketed bool
+		want      string
+	}{
+		{"a\nb", false, "a\rb"},
+		{"a\r\nb", true, "\x1b[200~a\rb\x1b[201~"},
+		{"x\x1b[201~rm -rf\x1b[200~", true, "\x1b[200~xrm -rf\x1b[201~"}, // Cannot end the paste early
+	}
+	for _, test := range tests {
+		if got := string(PasteSequence(test.text, test.bracketed)); got != test.want {
+			t.Errorf("pasting %q sends %q, expected %q", test.text, got, test.want)
+		}
+	}
+	s.WriteString("\x1b[?2004l")
+	if s.BracketedPaste() {
+		t.Error("bracketed paste was not turned off")
+	}
+}
+
+func TestScreenWrapPending(t *testing.T) {
+	s := NewScreen(4, 2)
+	s.WriteString("abcd") // Fills the line, but does not wrap yet
+	expectCursor(t, s, 3, 0)
+	if s.Wrapped(0) {
+		t.Error("line wrapped before another rune was written")
+	}
+
+	s.WriteString("e") // The next rune wraps
+	expectLines(t, s, "abcd", "e")
+	expectCursor(t, s, 1, 1)
+	if !s.Wrapped(0) {
+		t.Error("line did not wrap")
+	}
+
+	s.WriteString("\x1b[2;4Hf\r\ng") // CR clears the pending wrap
+	expectLines(t, s, "e  f", "g")
+}
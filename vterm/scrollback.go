@@ -0,0 +1,227 @@
+package vterm
+
+import "strings"
+
+// DefaultMaxScrollback is the MaxScrollback of a Screen made with NewScreen.
+const DefaultMaxScrollback = 1000
+
+// mainLines returns the lines of the main screen, whether or not it is shown.
+func (s *Screen) mainLines() [][]Cell {
+	if s.altActive {
+		return s.altLines
+	}
+	return s.lines
+}
+
+// pushScrollback adds a copy of the line to the end of the scrollback, removing
+// the oldest lines beyond MaxScrollback.
+func (s *Screen) pushScrollback(line []Cell) {
+	if s.MaxScrollback <= 0 {
+		return
+	}
+	s.scrollback = append(s.scrollback, append([]Cell(nil), line...))
+	if over := len(s.scrollback) - s.MaxScrollback; over > 0 {
+		copy(s.scrollback, s.scrollback[over:])
+		for i := len(s.scrollback) - over; i < len(s.scrollback); i++ {
+			s.scrollback[i] = nil
+		}
+		s.scrollback = s.scrollback[:len(s.scrollback)-over]
+	}
+	if s.viewOffset > 0 {
+		s.viewOffset++ // Keep viewing the same lines as output arrives
+	}
+	s.viewOffset = Min(s.viewOffset, len(s.scrollback))
+}
+
+// ClearScrollback forgets every line of the scrollback.
+func (s *Screen) ClearScrollback() {
+	s.scrollback = nil
+	s.viewOffset = 0
+}
+
+// ScrollbackLines returns the number of lines in the scrollback.
+func (s *Screen) ScrollbackLines() int {
+	return len(s.scrollback)
+}
+
+// ViewOffset returns the number of lines of scrollback above the Screen being
+// viewed. Zero is the live Screen.
+func (s *Screen) ViewOffset() int {
+	return s.viewOffset
+}
+
+// SetViewOffset changes the number of lines of scrollback above the Screen to
+// view, clamped to the scrollback. While viewing the scrollback, the view stays
+// on the same lines as new output arrives. The scrollback cannot be viewed
+// while the alternate screen is shown.
+func (s *Screen) SetViewOffset(offset int) {
+	s.viewOffset = Clamp(offset, 0, len(s.scrollback))
+}
+
+// ScrollView moves the view up into the scrollback by n lines, or down toward
+// the live Screen if n is negative.
+func (s *Screen) ScrollView(n int) {
+	s.SetViewOffset(s.viewOffset + n)
+}
+
+// ViewCell returns the cell at column x and row y of the view, which is the
+// Screen scrolled up by ViewOffset lines of scrollback.
+func (s *Screen) ViewCell(x, y int) Cell {
+	if s.altActive || s.viewOffset == 0 {
+		return s.Cell(x, y)
+	}
+	if y < s.viewOffset {
+		return s.HistoryCell(x, len(s.scrollback)-s.viewOffset+y)
+	}
+	return s.Cell(x, y-s.viewOffset)
+}
+
+// HistoryLines returns the number of lines in the scrollback and the main
+// screen combined. History lines start at zero with the oldest line of
+// scrollback, and the first line of the main screen is ScrollbackLines().
+func (s *Screen) HistoryLines() int {
+	return len(s.scrollback) + s.height
+}
+
+// HistoryCell returns the cell at column x of the history line. Positions
+// outside the history return a blank cell.
+func (s *Screen) HistoryCell(x, line int) Cell {
+	if line < 0 || x < 0 {
+		return blankCell(s.DefaultStyle)
+	}
+	if line < len(s.scrollback) {
+		if x < len(s.scrollback[line]) {
+			return s.scrollback[line][x]
+		}
+		return blankCell(s.DefaultStyle)
+	}
+	main := s.mainLines()
+	if line -= len(s.scrollback); line < len(main) && x < len(main[line]) {
+		return main[line][x]
+	}
+	return blankCell(s.DefaultStyle)
+}
+
+// HistoryWrapped returns true if the history line continues onto the next
+// line, because text reached the right margin.
+func (s *Screen) HistoryWrapped(line int) bool {
+	if line >= 0 && line < len(s.scrollback) {
+		cells := s.scrollback[line]
+		return len(cells) > 0 && cells[len(cells)-1].Wrapped
+	}
+	return s.HistoryCell(s.width-1, line).Wrapped
+}
+
+// HistoryText returns the text of the history line, without trailing spaces.
+// The second half of each double-wide rune is omitted.
+func (s *Screen) HistoryText(line int) string {
+	var cells []Cell
+	if line >= 0 && line < len(s.scrollback) {
+		cells = s.scrollback[line]
+	} else if main := s.mainLines(); line >= len(s.scrollback) && line-len(s.scrollback) < len(main) {
+		cells = main[line-len(s.scrollback)]
+	}
+	var sb strings.Builder
+	for _, cell := range cells {
+		if cell.Width == 0 {
+			continue
+		}
+		if cell.Rune == 0 {
+			sb.WriteRune(' ')
+		} else {
+			sb.WriteRune(cell.Rune)
+		}
+		for _, r := range cell.Combining {
+			sb.WriteRune(r)
+		}
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
+
+// Search finds the text in the history, beginning at the history line and
+// column, and moving forward or backward through the lines. The column is the
+// cell column. When searching backward, a match must begin before the column.
+// Returns the history line and column of the first cell of the match. Matches
+// spanning multiple lines are not found.
+func (s *Screen) Search(text string, line, col int, backward bool) (matchLine, matchCol int, found bool) {
+	if text == "" {
+		return 0, 0, false
+	}
+	lines := s.HistoryLines()
+	step := 1
+	if backward {
+		step = -1
+	}
+	for ; line >= 0 && line < lines; line += step {
+		cols := s.lineColumns(line)
+		str := s.HistoryText(line)
+		if backward {
+			end := len(str)
+			if col >= 0 && col < len(cols) {
+				end = Min(cols[col], len(str))
+			}
+			if i := strings.LastIndex(str[:end], text); i != -1 {
+				return line, byteColumn(cols, i), true
+			}
+		} else {
+			start := 0
+			if col > 0 && col < len(cols) {
+				start = Min(cols[col], len(str))
+			} else if col >= len(cols) {
+				start = len(str)
+			}
+			if i := strings.Index(str[start:], text); i != -1 {
+				return line, byteColumn(cols, start+i), true
+			}
+		}
+		// Search all of every line after the first
+		if backward {
+			col = -1
+		} else {
+			col = 0
+		}
+	}
+	return 0, 0, false
+}
+
+// lineColumns returns the byte offset into HistoryText(line) of each cell
+// column of the line, and of the end of the line. The second half of a
+// double-wide rune has the offset of the rune.
+func (s *Screen) lineColumns(line int) []int {
+	width := s.width
+	if line < len(s.scrollback) && line >= 0 {
+		width = len(s.scrollback[line])
+	}
+	cols := make([]int, width+1)
+	offset := 0
+	for x := 0; x < width; x++ {
+		cols[x] = offset
+		cell := s.HistoryCell(x, line)
+		if cell.Width == 0 {
+			if x > 0 {
+				cols[x] = cols[x-1]
+			}
+			continue
+		}
+		if cell.Rune == 0 {
+			offset++
+		} else {
+			offset += len(string(cell.Rune))
+		}
+		for _, r := range cell.Combining {
+			offset += len(string(r))
+		}
+	}
+	cols[width] = offset
+	return cols
+}
+
+// byteColumn returns the cell column of the byte offset into a line's text.
+func byteColumn(cols []int, offset int) int {
+	for x, o := range cols {
+		if o >= offset {
+			return x
+		}
+	}
+	return len(cols) - 1
+}
@@ -0,0 +1,38 @@
+package vterm
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestScrollbackViewFollowsOutput(t *testing.T) {
+	s := NewScreen(5, 2)
+	s.MaxScrollback = 3
+	for i := 0; i < 5; i++ {
+		s.WriteString(strconv.Itoa(i) + "\r\n")
+	}
+	s.SetViewOffset(2)
+	first := s.ViewCell(0, 0).Rune
+
+	// The scrollback is full, so each line drops the oldest
+	s.WriteString("5\r\n")
+	if got := s.ViewCell(0, 0).Rune; got != first {
+		t.Errorf("view moved from %q to %q as output arrived", first, got)
+	}
+	if s.ViewOffset() != 3 {
+		t.Errorf("view offset is %d, expected 3", s.ViewOffset())
+	}
+}
+
+func TestScrollbackSearch(t *testing.T) {
+	s := NewScreen(10, 2)
+	s.WriteString("foo\r\nbar\r\nfoo bar\r\n")
+	line, col, found := s.Search("bar", s.HistoryLines()-1, 0, true)
+	if !found || s.HistoryText(line) != "foo bar" || col != 4 {
+		t.Errorf("found %v at line %d, column %d", found, line, col)
+	}
+	line, _, found = s.Search("bar", line, col, true)
+	if !found || s.HistoryText(line) != "bar" {
+		t.Errorf("found %v at line %d", found, line)
+	}
+}
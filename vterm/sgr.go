@@ -0,0 +1,126 @@
+package vterm
+
+import "github.com/gdamore/tcell/v2"
+
+// selectGraphicRendition applies SGR parameters to the current style. Colors may
+// be one of the 16 standard colors, from the 256 color palette (38;5;n), or
+// truecolor (38;2;r;g;b). The colon separated forms are also accepted.
+func (s *Screen) selectGraphicRendition(params [][]int) {
+	if len(params) == 0 {
+		s.style = s.DefaultStyle
+		return
+	}
+
+	for i := 0; i < len(params); i++ {
+		code := params[i][0]
+		switch {
+		case code <= 0:
+			s.style = s.DefaultStyle
+		case code == 1:
+			s.style = s.style.Bold(true)
+		case code == 2:
+			s.style = s.style.Dim(true)
+		case code == 3:
+			s.style = s.style.Italic(true)
+		case code == 4:
+			s.style = s.style.Underline(true)
+		case code == 5 || code == 6:
+			s.style = s.style.Blink(true)
+		case code == 7:
+			s.style = s.style.Reverse(true)
+		case code == 9:
+			s.style = s.style.StrikeThrough(true)
+		case code == 21:
+			s.style = s.style.Underline(true) // Double underline
+		case code == 22:
+			s.style = s.style.Bold(false).Dim(false)
+		case code == 23:
+			s.style = s.style.Italic(false)
+		case code == 24:
+			s.style = s.style.Underline(false)
+		case code == 25:
+			s.style = s.style.Blink(false)
+		case code == 27:
+			s.style = s.style.Reverse(false)
+		case code == 29:
+			s.style = s.style.StrikeThrough(false)
+		case code >= 30 && code <= 37:
+			s.style = s.style.Foreground(tcell.PaletteColor(code - 30))
+		case code == 38:
+			var color tcell.Color
+			color, i = extendedColor(params, i)
+			if color != tcell.ColorDefault {
+				s.style = s.style.Foreground(color)
+			}
+		case code == 39:
+			fg, _, _ := s.DefaultStyle.Decompose()
+			s.style = s.style.Foreground(fg)
+		case code >= 40 && code <= 47:
+			s.style = s.style.Background(tcell.PaletteColor(code - 40))
+		case code == 48:
+			var color tcell.Color
+			color, i = extendedColor(params, i)
+			if color != tcell.ColorDefault {
+				s.style = s.style.Background(color)
+			}
+		case code == 49:
+			_, bg, _ := s.DefaultStyle.Decompose()
+			s.style = s.style.Background(bg)
+		case code >= 90 && code <= 97:
+			s.style = s.style.Foreground(tcell.PaletteColor(code - 90 + 8))
+		case code >= 100 && code <= 107:
+			s.style = s.style.Background(tcell.PaletteColor(code - 100 + 8))
+		}
+	}
+}
+
+// extendedColor parses the color of a 38 or 48 SGR parameter at params[i].
+// Returns the color, or tcell.ColorDefault if it is invalid, and the index of
+// the last parameter used.
+func extendedColor(params [][]int, i int) (tcell.Color, int) {
+	if subs := params[i]; len(subs) > 1 {
+		// Colon form: 38:5:n, 38:2:r:g:b, or 38:2:colorspace:r:g:b
+		switch subs[1] {
+		case 5:
+			if len(subs) > 2 {
+				return paletteColor(subs[2]), i
+			}
+		case 2:
+			if len(subs) >= 6 {
+				return rgbColor(subs[3], subs[4], subs[5]), i
+			} else if len(subs) == 5 {
+				return rgbColor(subs[2], subs[3], subs[4]), i
+			}
+		}
+		return tcell.ColorDefault, i
+	}
+
+	// Semicolon form: 38;5;n or 38;2;r;g;b
+	if i+1 >= len(params) {
+		return tcell.ColorDefault, i
+	}
+	switch params[i+1][0] {
+	case 5:
+		if i+2 < len(params) {
+			return paletteColor(params[i+2][0]), i + 2
+		}
+		return tcell.ColorDefault, len(params) - 1
+	case 2:
+		if i+4 < len(params) {
+			return rgbColor(params[i+2][0], params[i+3][0], params[i+4][0]), i + 4
+		}
+		return tcell.ColorDefault, len(params) - 1
+	}
+	return tcell.ColorDefault, i + 1
+}
+
+func paletteColor(n int) tcell.Color {
+	if n < 0 || n > 255 {
+		return tcell.ColorDefault
+	}
+	return tcell.PaletteColor(n)
+}
+
+func rgbColor(r, g, b int) tcell.Color {
+	return tcell.NewRGBColor(int32(Clamp(r, 0, 255)), int32(Clamp(g, 0, 255)), int32(Clamp(b, 0, 255)))
+}
@@ -0,0 +1,324 @@
+package vterm
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// TermName is the value of the TERM environment variable given to commands
+// started in a Terminal.
+const TermName = "xterm-256color"
+
+// A Terminal runs a command in a pseudo-terminal and interprets its output onto
+// a Screen. The output is read on its own goroutine, so the Screen must only be
+// accessed between calls to Lock and Unlock.
+//
+// Instead of running a command, a Terminal can Play an asciicast recording.
+// The session of a Terminal can be recorded with Record.
+type Terminal struct {
+	// OnUpdate is called after output from the command has changed the Screen.
+	// It is called on the goroutine reading the output, without the lock held.
+	OnUpdate func()
+	// OnExit is called when the command exits, with the error returned by
+	// exec.Cmd.Wait. It is called on the goroutine reading the output.
+	OnExit func(err error)
+
+	mu       sync.Mutex
+	screen   *Screen
+	cmd      *exec.Cmd
+	pty      *os.File
+	stop     chan struct{} // Receives to stop playing a recording
+	recorder *Recorder
+	done     chan struct{}
+	err      error // Result of waiting for the command
+}
+
+// Start runs the command in a new pseudo-terminal with the size in columns and
+// rows. The TERM environment variable of the command is set to TermName. To set
+// OnUpdate and OnExit before any output arrives, use NewTerminal and then call
+// Start on it, instead.
+func Start(cmd *exec.Cmd, width, height int) (*Terminal, error) {
+	t := NewTerminal(width, height)
+	if err := t.Start(cmd); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// NewTerminal makes a Terminal with a Screen of the size, but does not start a
+// command. Set any callbacks, then call Start.
+func NewTerminal(width, height int) *Terminal {
+	return &Terminal{
+		screen: NewScreen(width, height),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start runs the command in a new pseudo-terminal the size of the Screen. A
+// Terminal can only run one command.
+func (t *Terminal) Start(cmd *exec.Cmd) error {
+	if t.started() {
+		return errors.New("vterm: terminal already started")
+	}
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(withoutEnv(env, "TERM"), "TERM="+TermName)
+
+	t.mu.Lock()
+	width, height := t.screen.Size()
+	t.mu.Unlock()
+
+	f, err := pty.StartWithSize(cmd, &pty.Winsize{Rows: uint16(height), Cols: uint16(width)})
+	if err != nil {
+		return err
+	}
+	t.cmd = cmd
+	t.pty = f
+	t.screen.Reply = f
+	go t.readLoop()
+	return nil
+}
+
+func (t *Terminal) started() bool {
+	return t.cmd != nil || t.stop != nil
+}
+
+func withoutEnv(env []string, name string) []string {
+	result := make([]string, 0, len(env))
+	for _, v := range env {
+		if !strings.HasPrefix(v, name+"=") {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func (t *Terminal) readLoop() {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := t.pty.Read(buf)
+		if n > 0 {
+			t.mu.Lock()
+			_, _ = t.screen.Write(buf[:n])
+			if t.recorder != nil {
+				_, _ = t.recorder.Write(buf[:n])
+			}
+			t.mu.Unlock()
+			if t.OnUpdate != nil {
+				t.OnUpdate()
+			}
+		}
+		if err != nil {
+			break // EOF, or EIO on Linux, when the command has exited
+		}
+	}
+	t.err = t.cmd.Wait()
+	close(t.done)
+	if t.OnExit != nil {
+		t.OnExit(t.err)
+	}
+}
+
+// Play interprets the asciicast recording onto the Screen, waiting between the
+// events like the original session, on its own goroutine. The Screen is resized
+// to the size of the recording. Speed multiplies how fast the recording is
+// played; zero plays it without waiting. OnUpdate is called after each output
+// event, and OnExit is called at the end of the recording with any error
+// reading it. Input to a played Terminal is discarded.
+func (t *Terminal) Play(r io.Reader, speed float64) error {
+	if t.started() {
+		return errors.New("vterm: terminal already started")
+	}
+	c, err := NewCastReader(r)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.screen.Resize(c.Header.Width, c.Header.Height)
+	t.mu.Unlock()
+	t.stop = make(chan struct{})
+	go t.playLoop(c, speed)
+	return nil
+}
+
+func (t *Terminal) playLoop(c *CastReader, speed float64) {
+	start := time.Now()
+	for {
+		e, err := c.Next()
+		if err != nil {
+			if err != io.EOF {
+				t.err = err
+			}
+			break
+		}
+		if speed > 0 {
+			at := time.Duration(e.Time / speed * float64(time.Second))
+			timer := time.NewTimer(time.Until(start.Add(at)))
+			select {
+			case <-timer.C:
+			case <-t.stop:
+				timer.Stop()
+				t.err = errors.New("vterm: playing stopped")
+				close(t.done)
+				return // Closed by Close, so OnExit is not called
+			}
+		}
+		if e.Type == EventInput {
+			continue
+		}
+		t.mu.Lock()
+		t.screen.play(e)
+		if t.recorder != nil {
+			if e.Type == EventOutput {
+				_, _ = t.recorder.Write([]byte(e.Data))
+			} else if w, h, ok := e.Size(); ok {
+				_ = t.recorder.Resize(w, h)
+			}
+		}
+		t.mu.Unlock()
+		if t.OnUpdate != nil {
+			t.OnUpdate()
+		}
+	}
+	close(t.done)
+	if t.OnExit != nil {
+		t.OnExit(t.err)
+	}
+}
+
+// Record writes an asciicast v2 recording of the output from now on, and of
+// input and changes in size. Pass a nil writer to stop recording.
+func (t *Terminal) Record(w io.Writer, title string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if w == nil {
+		t.recorder = nil
+		return nil
+	}
+	width, height := t.screen.Size()
+	header := CastHeader{
+		Width:  width,
+		Height: height,
+		Title:  title,
+		Env:    map[string]string{"TERM": TermName},
+	}
+	r, err := NewRecorder(w, header)
+	if err != nil {
+		return err
+	}
+	t.recorder = r
+	return nil
+}
+
+// Lock must be held while accessing the Screen.
+func (t *Terminal) Lock() {
+	t.mu.Lock()
+}
+
+func (t *Terminal) Unlock() {
+	t.mu.Unlock()
+}
+
+// Screen returns the Screen the output of the command is interpreted onto. Hold
+// the lock while using it.
+func (t *Terminal) Screen() *Screen {
+	return t.screen
+}
+
+// Write sends input to the command, as though it was typed.
+func (t *Terminal) Write(p []byte) (int, error) {
+	if t.stop != nil {
+		return len(p), nil // Playing a recording
+	}
+	if t.pty == nil {
+		return 0, errors.New("vterm: terminal not started")
+	}
+	t.mu.Lock()
+	if t.recorder != nil {
+		_ = t.recorder.WriteInput(p)
+	}
+	t.mu.Unlock()
+	return t.pty.Write(p)
+}
+
+// WriteString sends input to the command, as though it was typed.
+func (t *Terminal) WriteString(s string) (int, error) {
+	return t.Write([]byte(s))
+}
+
+// Paste sends the text to the command as though it was pasted, bracketed if
+// the command asked for it. See PasteSequence.
+func (t *Terminal) Paste(text string) error {
+	t.mu.Lock()
+	bracketed := t.screen.BracketedPaste()
+	t.mu.Unlock()
+	_, err := t.Write(PasteSequence(text, bracketed))
+	return err
+}
+
+// Resize changes the size of the Screen and of the pseudo-terminal, which
+// notifies the command with SIGWINCH.
+func (t *Terminal) Resize(width, height int) error {
+	t.mu.Lock()
+	t.screen.Resize(width, height)
+	width, height = t.screen.Size()
+	if t.recorder != nil {
+		_ = t.recorder.Resize(width, height)
+	}
+	t.mu.Unlock()
+	if t.pty == nil {
+		return nil
+	}
+	return pty.Setsize(t.pty, &pty.Winsize{Rows: uint16(height), Cols: uint16(width)})
+}
+
+// Done returns a channel that is closed when the command has exited and all of
+// its output has been interpreted.
+func (t *Terminal) Done() <-chan struct{} {
+	return t.done
+}
+
+// Wait waits for the command to exit, and returns the error from exec.Cmd.Wait.
+func (t *Terminal) Wait() error {
+	if !t.started() {
+		return errors.New("vterm: terminal not started")
+	}
+	<-t.done
+	return t.err
+}
+
+// Close kills the command if it is still running, and closes the
+// pseudo-terminal. A recording being played is stopped.
+func (t *Terminal) Close() error {
+	if t.stop != nil {
+		select {
+		case <-t.done:
+		case t.stop <- struct{}{}:
+			<-t.done
+		}
+		return nil
+	}
+	if t.cmd == nil {
+		return nil
+	}
+	select {
+	case <-t.done:
+		return t.pty.Close()
+	default:
+	}
+	if t.cmd.Process != nil {
+		_ = t.cmd.Process.Kill()
+	}
+	err := t.pty.Close() // Unblocks reading if other processes hold the terminal
+	<-t.done
+	return err
+}
@@ -0,0 +1,35 @@
+package vterm
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestTerminalCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not installed")
+	}
+	term, err := Start(exec.Command("sh", "-c", `printf 'hello\r\n\033[1;31mred\033[0m\033[3;5Hthere'`), 20, 4)
+	if err != nil {
+		t.Skipf("cannot start a pseudo-terminal: %v", err)
+	}
+	defer term.Close()
+
+	select {
+	case <-term.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("command did not exit")
+	}
+	if err := term.Wait(); err != nil {
+		t.Fatalf("command failed: %v", err)
+	}
+
+	term.Lock()
+	defer term.Unlock()
+	s := term.Screen()
+	expectLines(t, s, "hello", "red", "    there")
+	if _, _, attrs := s.Cell(0, 1).Style.Decompose(); attrs == 0 {
+		t.Error("red text is not bold")
+	}
+}
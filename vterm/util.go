@@ -0,0 +1,22 @@
+package vterm
+
+// Min returns the smaller of the two values.
+func Min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Max returns the larger of the two values.
+func Max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Clamp keeps the input value within a range of [min, max].
+func Clamp(value, min, max int) int {
+	return Max(min, Min(value, max))
+}
@@ -35,8 +35,88 @@ type Widget interface {
 	DisplaySize(boundsW, boundsH int) (w, h int)
 	// Draw renders the Widget onto the terminal screen, bounded by the provided
 	// Rect. It is a bug if the Widget draws any part of itself outside the rect
-	// provided. Draw should not call Sync() on the tcell.Screen or other
-	// synchronizing functions, as all synchronization will be done by the event
-	// loop.
+	// provided, and containers like Box, Window and ScrollView pass a
+	// ClipScreen to their children to prevent it. Draw should not call Sync()
+	// on the tcell.Screen or other synchronizing functions, as all
+	// synchronization will be done by the event loop. The event loop only
+	// redraws after handling an event, so a Widget that changes otherwise must
+	// report it with App.Invalidate, or be a DamageReporter and have the App
+	// call RequestUpdate.
 	Draw(rect Rect, s tcell.Screen)
 }
+
+// A FocusReporter is a Widget that can report where its focused part is drawn,
+// so a ScrollView can keep it visible. Containers report the part of their
+// focused child.
+type FocusReporter interface {
+	// FocusRect returns the part of the Widget drawn in the rect that has the
+	// focus, or false if no part of it is focused.
+	FocusRect(rect Rect) (focus Rect, ok bool)
+}
+
+// focusRectOf returns the focused part of the Widget drawn in the rect, if the
+// Widget is a FocusReporter.
+func focusRectOf(w Widget, rect Rect) (Rect, bool) {
+	if r, ok := w.(FocusReporter); ok {
+		return r.FocusRect(rect)
+	}
+	return Rect{}, false
+}
+
+// A DamageReporter is a Widget that can report which parts of the screen it
+// changed, so the event loop redraws only those after the Widget handles a key,
+// instead of the whole screen. Containers report the damage of their children,
+// and any Widget that is not a DamageReporter is assumed to have changed the
+// whole screen.
+type DamageReporter interface {
+	// TakeDamage returns the rects of the screen the Widget changed since the
+	// last call, and forgets them. Returns all as true if the whole screen must
+	// be redrawn, like after calling a callback that could change anything.
+	TakeDamage() (rects []Rect, all bool)
+}
+
+// damageOf returns the damage of the Widget, which is the whole screen if it is
+// not a DamageReporter.
+func damageOf(w Widget) (rects []Rect, all bool) {
+	if r, ok := w.(DamageReporter); ok {
+		return r.TakeDamage()
+	}
+	return nil, true
+}
+
+// damage collects the parts of the screen a Widget changed, for implementing
+// DamageReporter.
+type damage struct {
+	rects []Rect
+	all   bool
+}
+
+// add records the rects as changed, or the whole screen if none are given.
+func (d *damage) add(rects ...Rect) {
+	if len(rects) == 0 {
+		d.all = true
+	} else if !d.all {
+		d.rects = append(d.rects, rects...)
+	}
+}
+
+// addWidget records the damage of a child Widget.
+func (d *damage) addWidget(w Widget) {
+	if w == nil {
+		return
+	}
+	if rects, all := damageOf(w); all {
+		d.all = true
+	} else if len(rects) > 0 {
+		d.add(rects...)
+	}
+}
+
+func (d *damage) take() (rects []Rect, all bool) {
+	rects, all = d.rects, d.all
+	d.rects, d.all = nil, false
+	if all {
+		rects = nil
+	}
+	return rects, all
+}
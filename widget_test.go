@@ -0,0 +1,62 @@
+package dos
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// newTestScreen returns an initialized tcell.SimulationScreen of the size.
+func newTestScreen(t *testing.T, w, h int) tcell.SimulationScreen {
+	t.Helper()
+	s := tcell.NewSimulationScreen("")
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	s.SetSize(w, h)
+	t.Cleanup(s.Fini)
+	return s
+}
+
+func TestTakeDamage(t *testing.T) {
+	input := &TextInput{}
+	edited := &TextInput{OnTextEdited: func(string) {}}
+	column := &Column{Children: []Widget{&Label{Text: "Name"}, input, edited}}
+	scaffold := &Scaffold{MainWidget: column, StatusBar: &StatusBar{}}
+	scaffold.SetFocused(true)
+	scaffold.Draw(Rect{0, 0, 20, 10}, newTestScreen(t, 20, 10))
+	if _, all := damageOf(scaffold); all {
+		t.Fatal("damaged the whole screen before any key")
+	}
+
+	tests := []struct {
+		focus int
+		key   *tcell.EventKey
+		rects []Rect
+		all   bool
+	}{
+		{1, tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone), []Rect{{0, 1, 20, 1}}, false},
+		{1, tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone), []Rect{{0, 1, 20, 1}}, false},
+		{1, tcell.NewEventKey(tcell.KeyF5, 0, tcell.ModNone), nil, false}, // Not handled
+		{2, tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone), nil, true},
+		{2, tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone), []Rect{{0, 2, 20, 1}}, false},
+	}
+	for i, test := range tests {
+		column.SetFocused(false)
+		column.FocusedIndex = test.focus
+		column.SetFocused(true)
+		scaffold.HandleKey(test.key)
+		rects, all := damageOf(scaffold)
+		if all != test.all || !reflect.DeepEqual(rects, test.rects) {
+			t.Errorf("key %d damaged %v, %v, expected %v, %v", i, rects, all, test.rects, test.all)
+		}
+	}
+
+	// Moving the focus to the MenuBar changes the whole screen
+	scaffold.MenuBar = &MenuBar{Menus: []MenuBarItem{{Title: "&File"}}}
+	scaffold.HandleKey(tcell.NewEventKey(tcell.KeyF10, 0, tcell.ModNone))
+	if _, all := damageOf(scaffold); !all {
+		t.Error("F10 did not damage the whole screen")
+	}
+}
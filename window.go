@@ -106,6 +106,21 @@ func (w *Window) SetFocused(b bool) {
 	}
 }
 
+func (w *Window) FocusRect(rect Rect) (Rect, bool) {
+	if w.Child == nil {
+		return Rect{}, false
+	}
+	return focusRectOf(w.Child, *w.GetChildRect(rect))
+}
+
+// TakeDamage reports the damage of the Child.
+func (w *Window) TakeDamage() ([]Rect, bool) {
+	if w.Child == nil {
+		return nil, false
+	}
+	return damageOf(w.Child)
+}
+
 func (w *Window) DisplaySize(boundsW, boundsH int) (int, int) {
 	return boundsW, boundsH
 }
@@ -125,8 +140,8 @@ func (w *Window) Draw(rect Rect, s tcell.Screen) {
 	if !w.HideClose {
 		DrawString(rect.X, rect.Y, " X ", w.CloseButtonStyle, s)
 	}
-	// Draw child
+	// Draw child, inside the window below the title bar
 	if w.Child != nil {
-		w.Child.Draw(*w.GetChildRect(rect), s)
+		w.Child.Draw(*w.GetChildRect(rect), NewClipScreen(s, Rect{rect.X, rect.Y + 1, rect.W, rect.H - 1}))
 	}
 }